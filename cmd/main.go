@@ -1,11 +1,91 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
 	"github.com/ZakirAvrora/exchange-rate/config"
 	"github.com/ZakirAvrora/exchange-rate/internal/app"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
 )
 
 func main() {
-	cfg := config.NewConfig(".env")
-	app.Run(cfg)
+	// Client commands call a running instance and need no configuration
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "refresh":
+			exit(app.Refresh(os.Args[2:], os.Stdout))
+			return
+		case "get":
+			exit(app.Get(os.Args[2:], os.Stdout))
+			return
+		}
+	}
+
+	cfg, args, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Reloads read the same file, environment and flags
+	flags := os.Args[1 : len(os.Args)-len(args)]
+
+	var command string
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+
+	l, err := logger.New(logger.Level(levelVar), logger.Format(cfg.Log.Format))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	slog.SetDefault(l)
+
+	reload := app.Reload{
+		Load: func() (*config.Config, error) {
+			cfg, _, err := config.Load(flags, io.Discard)
+			return cfg, err
+		},
+		Level: levelVar,
+	}
+
+	switch command {
+	case "":
+		app.Run(cfg, l, reload)
+	case "serve":
+		exit(app.Serve(cfg, l, reload, args, os.Stdout))
+	case "worker":
+		exit(app.Worker(cfg, l, reload, args, os.Stdout))
+	case "migrate":
+		exit(app.Migrate(cfg, l, args, os.Stdout))
+	case "import":
+		exit(app.Import(cfg, l, args, os.Stdout))
+	case "backfill":
+		exit(app.Backfill(cfg, l, args, os.Stdout))
+	case "config":
+		exit(app.Config(cfg, args, os.Stdout))
+	default:
+		log.Fatalf("unknown command %q", command)
+	}
+}
+
+func exit(err error) {
+	if err != nil {
+		log.Fatalln(err)
+	}
 }
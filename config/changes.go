@@ -0,0 +1,25 @@
+package config
+
+import "reflect"
+
+// Changes returns the paths of the fields whose values differ in next,
+// section by section, e.g. queue.workers.
+func (c *Config) Changes(next *Config) []string {
+	current, updated := c.fields(), next.fields()
+
+	var paths []string
+	for i := range current {
+		if !equal(current[i].value, updated[i].value) {
+			paths = append(paths, current[i].path)
+		}
+	}
+	return paths
+}
+
+// equal is reflect.DeepEqual, except that nil and empty lists are equal.
+func equal(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -1,50 +1,312 @@
 package config
 
 import (
-	"strconv"
+	"time"
 
-	"github.com/ZakirAvrora/exchange-rate/pkg/env"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates/repo"
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/ZakirAvrora/exchange-rate/internal/outbox"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
 )
 
-const _defaultMaxPoolSize = 1
+const (
+	_defaultHTTPPort            = "8080"
+	_defaultHTTPReadTimeout     = 5 * time.Second
+	_defaultHTTPWriteTimeout    = 5 * time.Second
+	_defaultHTTPShutdownTimeout = 3 * time.Second
+	_defaultMaxPoolSize         = 10
+	_defaultConnAttempts        = 5
+	_defaultConnTimeout         = time.Second
+	_defaultRetryPollInterval   = 10 * time.Second
+	_defaultTracingSampleRatio  = 1.0
+	_defaultHealthProbeEvery    = 5 * time.Minute
+	_defaultShutdownDelay       = 5 * time.Second
+	_defaultHTTPCacheSize       = 1000
+	_defaultHTTPCacheTTL        = 30 * time.Second
+	_defaultQueuePollInterval   = time.Second
+	_defaultProviderKeysPoll    = 30 * time.Second
+)
 
+// Config is the configuration of the service. Every field is set, in order
+// of precedence, by a flag named after its YAML path, an environment
+// variable named by its env tag, the YAML file or its default, see Load.
+// Fields tagged secret are redacted when printed, and may be read from the
+// file named by the variable suffixed with _FILE instead.
 type Config struct {
-	HTTP           HTTP
-	PostgresConfig PostgresConfig
+	HTTP           HTTP           `yaml:"http"`
+	PostgresConfig PostgresConfig `yaml:"postgres"`
+	Outbox         Outbox         `yaml:"outbox"`
+	Alerts         Alerts         `yaml:"alerts"`
+	Guard          Guard          `yaml:"guard"`
+	Retry          Retry          `yaml:"retry"`
+	Tracing        Tracing        `yaml:"tracing"`
+	Admin          Admin          `yaml:"admin"`
+	Log            Log            `yaml:"log"`
+	Health         Health         `yaml:"health"`
+	RateLimit      RateLimit      `yaml:"rate_limit"`
+	Idempotency    Idempotency    `yaml:"idempotency"`
+	HTTPCache      HTTPCache      `yaml:"http_cache"`
+	RecordsCache   RecordsCache   `yaml:"records_cache"`
+	Queue          Queue          `yaml:"queue"`
+	Migrations     Migrations     `yaml:"migrations"`
+	Provider       Provider       `yaml:"provider"`
+	Currencies     Currencies     `yaml:"currencies"`
 }
 
 type HTTP struct {
-	Port string
+	Port         string        `yaml:"port"          env:"HTTP_PORT"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"  env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	// ShutdownTimeout is how long in-flight requests are waited for on
+	// shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
 }
 
 type PostgresConfig struct {
-	Host     string
-	Port     string
-	DbName   string
-	User     string
-	Password string
-	PoolMax  int
-}
-
-func NewConfig(path string) *Config {
-	env.CheckDotEnv(path)
-	maxPool, err := strconv.Atoi(env.MustGet("PG_POOL_MAX"))
-	if err != nil {
-		// NoReturnErr: use defaultMaxPoolSize
-		maxPool = _defaultMaxPoolSize
-	}
+	Host     string `yaml:"host"     env:"PG_DATABASE_HOST"`
+	Port     string `yaml:"port"     env:"PG_DATABASE_PORT"`
+	DbName   string `yaml:"db_name"  env:"PG_DATABASE_DB"`
+	User     string `yaml:"user"     env:"PG_DATABASE_USER"`
+	Password string `yaml:"password" env:"PG_DATABASE_PASSWORD" secret:"true"`
+	PoolMax  int    `yaml:"pool_max" env:"PG_POOL_MAX"`
+	// ConnAttempts and ConnTimeout bound how long the database is waited for
+	// at start.
+	ConnAttempts int           `yaml:"conn_attempts" env:"PG_CONN_ATTEMPTS"`
+	ConnTimeout  time.Duration `yaml:"conn_timeout"  env:"PG_CONN_TIMEOUT"`
+}
+
+// Outbox configures where record lifecycle events are published.
+type Outbox struct {
+	// Publisher is one of "stdout", "file" or "http".
+	Publisher    string        `yaml:"publisher"     env:"OUTBOX_PUBLISHER"`
+	FilePath     string        `yaml:"file_path"     env:"OUTBOX_FILE_PATH"`
+	HTTPURL      string        `yaml:"http_url"      env:"OUTBOX_HTTP_URL"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+}
+
+// Alerts configures how triggered alert rules are delivered.
+type Alerts struct {
+	// Notifier is one of "log" or "webhook".
+	Notifier   string `yaml:"notifier"    env:"ALERTS_NOTIFIER"`
+	WebhookURL string `yaml:"webhook_url" env:"ALERTS_WEBHOOK_URL"`
+}
+
+// Guard configures the band outside of which provider ticks are quarantined.
+type Guard struct {
+	// MaxDeviation is the accepted deviation from the recent median, in percent.
+	MaxDeviation float64       `yaml:"max_deviation" env:"GUARD_MAX_DEVIATION"`
+	Window       time.Duration `yaml:"window"        env:"GUARD_WINDOW"`
+	MinSamples   int           `yaml:"min_samples"   env:"GUARD_MIN_SAMPLES"`
+}
+
+// Retry configures the attempt budget of records that failed transiently.
+type Retry struct {
+	MaxAttempts  int           `yaml:"max_attempts"  env:"RETRY_MAX_ATTEMPTS"`
+	BaseDelay    time.Duration `yaml:"base_delay"    env:"RETRY_BASE_DELAY"`
+	MaxDelay     time.Duration `yaml:"max_delay"     env:"RETRY_MAX_DELAY"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"RETRY_POLL_INTERVAL"`
+}
+
+type Tracing struct {
+	// Exporter is one of "none", "stdout" or "otlp".
+	Exporter     string  `yaml:"exporter"      env:"TRACING_EXPORTER"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName  string  `yaml:"service_name"  env:"TRACING_SERVICE_NAME"`
+	SampleRatio  float64 `yaml:"sample_ratio"  env:"TRACING_SAMPLE_RATIO"`
+}
+
+type Admin struct {
+	// Token protects the admin endpoints, which are disabled when it is empty.
+	Token string `yaml:"token" env:"ADMIN_TOKEN" secret:"true"`
+	// APIKeysRequired rejects v1 requests without an API key.
+	APIKeysRequired bool `yaml:"api_keys_required" env:"API_KEYS_REQUIRED"`
+}
+
+type Log struct {
+	// Level is one of "debug", "info", "warn" or "error".
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format is one of "json" or "text".
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// Health configures the liveness and readiness checks.
+type Health struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+	// ProviderProbe adds the provider API to the readiness checks. Probes
+	// count against the API quota, so they run once per ProviderProbeInterval.
+	ProviderProbe         bool          `yaml:"provider_probe"          env:"HEALTH_PROVIDER_PROBE"`
+	ProviderProbeInterval time.Duration `yaml:"provider_probe_interval" env:"HEALTH_PROVIDER_PROBE_INTERVAL"`
+	// ShutdownDelay is how long readiness fails before the server stops
+	// accepting connections, so load balancers drain it first.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"HEALTH_SHUTDOWN_DELAY"`
+}
+
+// RateLimit configures the per-client token buckets of the route groups.
+type RateLimit struct {
+	// Store is one of "memory" or "postgres", which shares buckets across replicas.
+	Store string `yaml:"store" env:"RATE_LIMIT_STORE"`
+	// Read and Refresh are limits such as "30/m"; empty or "0" disables them.
+	// Bursts default to the rate.
+	Read         string `yaml:"read"          env:"RATE_LIMIT_READ"`
+	ReadBurst    int    `yaml:"read_burst"    env:"RATE_LIMIT_READ_BURST"`
+	Refresh      string `yaml:"refresh"       env:"RATE_LIMIT_REFRESH"`
+	RefreshBurst int    `yaml:"refresh_burst" env:"RATE_LIMIT_REFRESH_BURST"`
+}
+
+type Idempotency struct {
+	// TTL is how long an Idempotency-Key is remembered.
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+}
+
+// HTTPCache configures caching of the read endpoints.
+type HTTPCache struct {
+	// MaxAge lets clients reuse latest rates without revalidating them.
+	MaxAge time.Duration `yaml:"max_age" env:"HTTP_CACHE_MAX_AGE"`
+	// Enabled keeps up to Size served records in memory. Latest rates are
+	// kept for TTL at most.
+	Enabled bool          `yaml:"enabled" env:"HTTP_CACHE_ENABLED"`
+	Size    int           `yaml:"size"    env:"HTTP_CACHE_SIZE"`
+	TTL     time.Duration `yaml:"ttl"     env:"HTTP_CACHE_TTL"`
+}
+
+// RecordsCache configures the cache of latest records in front of the
+// database.
+type RecordsCache struct {
+	Enabled bool          `yaml:"enabled" env:"RECORDS_CACHE_ENABLED"`
+	Size    int           `yaml:"size"    env:"RECORDS_CACHE_SIZE"`
+	TTL     time.Duration `yaml:"ttl"     env:"RECORDS_CACHE_TTL"`
+	// NegativeTTL is how long pairs without a rate are remembered.
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"RECORDS_CACHE_NEGATIVE_TTL"`
+}
+
+// Migrations configures how the database schema is migrated.
+type Migrations struct {
+	// OnStart applies pending migrations before serving. When it is false
+	// migrations are applied with the migrate command.
+	OnStart bool `yaml:"on_start" env:"MIGRATE_ON_START"`
+}
+
+// Queue configures the workers that refresh records.
+type Queue struct {
+	Workers int `yaml:"workers" env:"QUEUE_WORKERS"`
+	// Size is how many records may wait for a worker of a process.
+	Size int `yaml:"size" env:"QUEUE_SIZE"`
+	// Lease is how long a worker process may take to refresh a record it
+	// claimed from the database before another one claims it again.
+	Lease time.Duration `yaml:"lease" env:"QUEUE_LEASE"`
+	// PollInterval is how often worker processes look for pending records.
+	PollInterval time.Duration `yaml:"poll_interval" env:"QUEUE_POLL_INTERVAL"`
+}
+
+// Provider configures the exchange rates API.
+type Provider struct {
+	URL string `yaml:"url" env:"PROVIDER_URL"`
+	// APIKeys are used in order, rejected keys fail over to the next one
+	// and are tried last for KeyCooldown.
+	APIKeys []string `yaml:"api_keys" env:"PROVIDER_API_KEYS" secret:"true"`
+	// APIKeysFile holds the keys instead of APIKeys, one per line. It is
+	// read again every APIKeysPollInterval, so keys rotate without a restart.
+	APIKeysFile         string        `yaml:"api_keys_file"          env:"PROVIDER_API_KEYS_FILE"`
+	APIKeysPollInterval time.Duration `yaml:"api_keys_poll_interval" env:"PROVIDER_API_KEYS_POLL_INTERVAL"`
+	KeyCooldown         time.Duration `yaml:"key_cooldown"           env:"PROVIDER_KEY_COOLDOWN"`
+	Timeout             time.Duration `yaml:"timeout"                env:"PROVIDER_TIMEOUT"`
+}
+
+// Currencies are the codes of the supported currencies. Environment
+// variables list them separated by commas.
+type Currencies struct {
+	Base      []string `yaml:"base"      env:"CURRENCIES_BASE"`
+	Secondary []string `yaml:"secondary" env:"CURRENCIES_SECONDARY"`
+}
+
+// Default returns the configuration used for fields that are not set.
+func Default() *Config {
+	base, secondary := exchangerates.DefaultCurrencies()
 
 	return &Config{
 		HTTP: HTTP{
-			Port: "8080",
+			Port:            _defaultHTTPPort,
+			ReadTimeout:     _defaultHTTPReadTimeout,
+			WriteTimeout:    _defaultHTTPWriteTimeout,
+			ShutdownTimeout: _defaultHTTPShutdownTimeout,
 		},
 		PostgresConfig: PostgresConfig{
-			Host:     env.MustGet("PG_DATABASE_HOST"),
-			Port:     env.MustGet("PG_DATABASE_PORT"),
-			User:     env.MustGet("PG_DATABASE_USER"),
-			DbName:   env.MustGet("PG_DATABASE_DB"),
-			Password: env.MustGet("PG_DATABASE_PASSWORD"),
-			PoolMax:  maxPool,
+			PoolMax:      _defaultMaxPoolSize,
+			ConnAttempts: _defaultConnAttempts,
+			ConnTimeout:  _defaultConnTimeout,
+		},
+		Outbox: Outbox{
+			Publisher:    "stdout",
+			FilePath:     "outbox.ndjson",
+			PollInterval: outbox.DefaultPollInterval,
+		},
+		Alerts: Alerts{
+			Notifier: "log",
+		},
+		Guard: Guard{
+			MaxDeviation: exchangerates.DefaultGuardMaxDeviation,
+			Window:       exchangerates.DefaultGuardWindow,
+			MinSamples:   exchangerates.DefaultGuardMinSamples,
+		},
+		Retry: Retry{
+			MaxAttempts:  exchangerates.DefaultRetryMaxAttempts,
+			BaseDelay:    exchangerates.DefaultRetryBaseDelay,
+			MaxDelay:     exchangerates.DefaultRetryMaxDelay,
+			PollInterval: _defaultRetryPollInterval,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "exchange-rate",
+			SampleRatio: _defaultTracingSampleRatio,
+		},
+		Admin: Admin{
+			APIKeysRequired: true,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		RateLimit: RateLimit{
+			Store:   "memory",
+			Read:    "600/m",
+			Refresh: "30/m",
+		},
+		Idempotency: Idempotency{
+			TTL: exchangerates.DefaultIdempotencyTTL,
+		},
+		HTTPCache: HTTPCache{
+			Size: _defaultHTTPCacheSize,
+			TTL:  _defaultHTTPCacheTTL,
+		},
+		RecordsCache: RecordsCache{
+			Size:        repo.DefaultCacheSize,
+			TTL:         repo.DefaultCacheTTL,
+			NegativeTTL: repo.DefaultCacheNegativeTTL,
+		},
+		Queue: Queue{
+			Workers:      DefaultQueueWorkers,
+			Size:         exchangerates.DefaultQueueSize,
+			Lease:        exchangerates.DefaultQueueLease,
+			PollInterval: _defaultQueuePollInterval,
+		},
+		Migrations: Migrations{
+			OnStart: true,
+		},
+		Provider: Provider{
+			URL:                 exchangeratesapi.BASE_URL,
+			APIKeysPollInterval: _defaultProviderKeysPoll,
+			KeyCooldown:         exchangeratesapi.DefaultKeyCooldown,
+			Timeout:             exchangeratesapi.DefaultTimeout,
+		},
+		Currencies: Currencies{
+			Base:      base,
+			Secondary: secondary,
+		},
+		Health: Health{
+			CheckTimeout:          health.DefaultTimeout,
+			ProviderProbeInterval: _defaultHealthProbeEvery,
+			ShutdownDelay:         _defaultShutdownDelay,
 		},
 	}
 }
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("PG_DATABASE_HOST", "localhost")
+	t.Setenv("PG_DATABASE_PORT", "5432")
+	t.Setenv("PG_DATABASE_DB", "exchangerates")
+	t.Setenv("PG_DATABASE_USER", "root")
+	t.Setenv("PG_DATABASE_PASSWORD", "secret")
+	t.Setenv("PROVIDER_API_KEYS", "primary,secondary")
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	setRequiredEnv(t)
+	file := writeFile(t, `
+queue:
+  workers: 7
+  size: 20
+  lease: 1m
+currencies:
+  secondary: [USD, MXN]
+`)
+	t.Setenv("QUEUE_SIZE", "30")
+	t.Setenv("QUEUE_LEASE", "2m")
+
+	cfg, args, err := Load([]string{"-env-file", "none", "-config", file, "-queue.lease", "3m", "serve", "-port", "9090"}, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, []string{"serve", "-port", "9090"}, args)
+
+	require.Equal(t, 7, cfg.Queue.Workers)
+	require.Equal(t, 30, cfg.Queue.Size)
+	require.Equal(t, 3*time.Minute, cfg.Queue.Lease)
+	require.Equal(t, _defaultQueuePollInterval, cfg.Queue.PollInterval)
+	require.Equal(t, []string{"USD", "MXN"}, cfg.Currencies.Secondary)
+	require.Equal(t, []string{"EUR"}, cfg.Currencies.Base)
+	require.Equal(t, "8080", cfg.HTTP.Port)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("QUEUE_WORKERS", "many")
+	t.Setenv("CURRENCIES_SECONDARY", "usd,MXN")
+
+	_, _, err := Load([]string{"-env-file", "none", "-http.port", "0", "-log.level", "loud"}, io.Discard)
+	require.ErrorIs(t, err, ErrInvalid)
+
+	// Every error is reported at once
+	for _, want := range []string{
+		`QUEUE_WORKERS: "many" is not an integer`,
+		`http.port: "0" is not a port`,
+		`log.level: "loud"`,
+		`currencies.secondary: "usd" is not a currency code`,
+	} {
+		require.ErrorContains(t, err, want)
+	}
+
+	_, _, err = Load([]string{"-env-file", "none", "-config", writeFile(t, "queue:\n  worker: 7\n")}, io.Discard)
+	require.ErrorIs(t, err, ErrInvalid)
+	require.ErrorContains(t, err, "field worker not found")
+}
+
+func TestConfig_Changes(t *testing.T) {
+	current := Default()
+	next := Default()
+	require.Empty(t, current.Changes(next))
+
+	next.Queue.Workers = 9
+	next.Currencies.Secondary = append([]string{"CHF"}, next.Currencies.Secondary...)
+	next.Log.Level = "debug"
+	require.Equal(t, []string{"log.level", "queue.workers", "currencies.secondary"}, current.Changes(next))
+
+	// Unset and empty lists are the same
+	current.Provider.APIKeys, next.Provider.APIKeys = nil, []string{}
+	require.NotContains(t, current.Changes(next), "provider.api_keys")
+}
+
+func TestLoad_EnvFileReload(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("LOG_LEVEL", "warn")
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	args := []string{"-env-file", envFile}
+	// Unsets the variables set from the file
+	t.Cleanup(func() {
+		require.NoError(t, os.Remove(envFile))
+		_, _, err := Load(args, io.Discard)
+		require.NoError(t, err)
+	})
+
+	require.NoError(t, os.WriteFile(envFile, []byte("QUEUE_WORKERS=3\nCURRENCIES_SECONDARY=USD\nLOG_LEVEL=debug\n"), 0o600))
+	cfg, _, err := Load(args, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, 3, cfg.Queue.Workers)
+	require.Equal(t, []string{"USD"}, cfg.Currencies.Secondary)
+	require.Equal(t, "warn", cfg.Log.Level)
+
+	// Variables of the file change on reload, the environment still wins
+	require.NoError(t, os.WriteFile(envFile, []byte("QUEUE_WORKERS=7\nLOG_LEVEL=debug\n"), 0o600))
+	cfg, _, err = Load(args, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, 7, cfg.Queue.Workers)
+	require.Equal(t, Default().Currencies.Secondary, cfg.Currencies.Secondary)
+	require.Equal(t, "warn", cfg.Log.Level)
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("PG_DATABASE_PASSWORD", "")
+	t.Setenv("PROVIDER_API_KEYS", "")
+
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(password, []byte("from-file\n"), 0o600))
+	t.Setenv("PG_DATABASE_PASSWORD_FILE", password)
+	t.Setenv("PROVIDER_API_KEYS_FILE", filepath.Join(dir, "keys"))
+
+	cfg, _, err := Load([]string{"-env-file", "none"}, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, "from-file", cfg.PostgresConfig.Password)
+	// The keys file is read by the provider client, which watches it
+	require.Equal(t, filepath.Join(dir, "keys"), cfg.Provider.APIKeysFile)
+	require.Empty(t, cfg.Provider.APIKeys)
+
+	t.Setenv("PROVIDER_API_KEYS", "key")
+	_, _, err = Load([]string{"-env-file", "none"}, io.Discard)
+	require.ErrorContains(t, err, "provider.api_keys: must not be set along with provider.api_keys_file")
+
+	t.Setenv("PG_DATABASE_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	_, _, err = Load([]string{"-env-file", "none"}, io.Discard)
+	require.ErrorContains(t, err, "PG_DATABASE_PASSWORD_FILE")
+}
+
+func TestConfig_Print(t *testing.T) {
+	cfg := Default()
+	cfg.PostgresConfig.Password = "secret"
+	cfg.Admin.Token = ""
+	cfg.Provider.APIKeys = []string{"primary-key", "secondary-key"}
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	require.Contains(t, out.String(), "password: REDACTED")
+	require.Contains(t, out.String(), `token: ""`)
+	require.NotContains(t, out.String(), "secret")
+	require.NotContains(t, out.String(), "-key")
+	require.Equal(t, "secret", cfg.PostgresConfig.Password)
+	require.Equal(t, []string{"primary-key", "secondary-key"}, cfg.Provider.APIKeys)
+
+	// The output is a valid configuration file
+	setRequiredEnv(t)
+	loaded, _, err := Load([]string{"-env-file", "none", "-config", writeFile(t, out.String())}, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Queue, loaded.Queue)
+}
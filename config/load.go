@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/env"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a setting of the configuration, e.g. queue.workers.
+type field struct {
+	path   string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// flagValue holds a flag until the file and environment are applied.
+type flagValue struct {
+	field *field
+	isSet bool
+	raw   string
+}
+
+func (v *flagValue) String() string { return "" }
+
+func (v *flagValue) Set(s string) error {
+	v.raw = s
+	v.isSet = true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.field != nil && v.field.value.Kind() == reflect.Bool
+}
+
+// Load returns the configuration layered from the defaults, the YAML file,
+// the environment and the flags at the start of args, then validated. It
+// also returns the arguments after the flags, i.e. the command.
+//
+// The file is set with -config or CONFIG_FILE. Variables of the .env file
+// set with -env-file, .env by default, are added to the environment unless
+// they are set already.
+func Load(args []string, out io.Writer) (*Config, []string, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("exchange-rate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	file := fs.String("config", "", "YAML configuration file, CONFIG_FILE by default")
+	envFile := fs.String("env-file", ".env", "file of environment variables")
+
+	values := make([]*flagValue, len(fields))
+	for i := range fields {
+		values[i] = &flagValue{field: &fields[i]}
+		fs.Var(values[i], flagName(fields[i].path), flagUsage(fields[i]))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := env.LoadDotEnv(*envFile); err != nil {
+		return nil, nil, fmt.Errorf("config error: loading %s is failed: %w", *envFile, err)
+	}
+
+	if *file == "" {
+		*file = os.Getenv("CONFIG_FILE")
+	}
+	if *file != "" {
+		if err := cfg.loadFile(*file); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	envNames := make(map[string]bool)
+	for _, f := range fields {
+		envNames[f.env] = true
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if raw := os.Getenv(f.env); raw != "" {
+			if err := f.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+			continue
+		}
+
+		// Secrets mounted as files, unless a field is named like the file
+		fileEnv := f.env + "_FILE"
+		if path := os.Getenv(fileEnv); f.secret && path != "" && !envNames[fileEnv] {
+			data, err := os.ReadFile(path)
+			if err == nil {
+				err = f.set(string(data))
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", fileEnv, err))
+			}
+		}
+	}
+	for _, v := range values {
+		if v.isSet {
+			if err := v.field.set(v.raw); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", flagName(v.field.path), err))
+			}
+		}
+	}
+
+	// Fields that could not be parsed keep their value, so the others are
+	// validated as well.
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("config error: %w: %w", ErrInvalid, errors.Join(errs...))
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// loadFile sets the fields of the YAML file. Unknown fields are errors, so
+// that typos are not silently ignored.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config error: %w: reading %s is failed: %w", ErrInvalid, path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config error: %w: parsing %s is failed: %w", ErrInvalid, path, err)
+	}
+	return nil
+}
+
+// fields returns the settings of the configuration, section by section.
+func (c *Config) fields() []field {
+	var fields []field
+
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := yamlName(sections.Type().Field(i))
+
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			fields = append(fields, field{
+				path:   sectionName + "." + yamlName(sf),
+				env:    sf.Tag.Get("env"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+
+	return fields
+}
+
+// set parses raw into the field. Lists are separated by commas or lines.
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", raw)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(raw)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		f.value.SetFloat(n)
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Slice:
+		var list []string
+		for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+
+	return nil
+}
+
+func yamlName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+	return name
+}
+
+// flagName is the path of the field with dashes, e.g. -queue.poll-interval.
+func flagName(path string) string {
+	return strings.ReplaceAll(path, "_", "-")
+}
+
+func flagUsage(f field) string {
+	if f.env == "" {
+		return "overrides " + f.path
+	}
+	return "overrides " + f.path + " and " + f.env
+}
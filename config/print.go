@@ -0,0 +1,41 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const _redacted = "REDACTED"
+
+// Print writes the configuration as a YAML file, with the secrets that are
+// set redacted.
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	for _, f := range redacted.fields() {
+		if !f.secret {
+			continue
+		}
+		switch f.value.Kind() {
+		case reflect.String:
+			if f.value.String() != "" {
+				f.value.SetString(_redacted)
+			}
+		case reflect.Slice:
+			// The slice is shared with c, it is replaced rather than changed
+			values := make([]string, f.value.Len())
+			for i := range values {
+				values[i] = _redacted
+			}
+			f.value.Set(reflect.ValueOf(values))
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+// MaxQueueWorkers is the most workers a process runs.
+const MaxQueueWorkers = 100
+
+// DefaultQueueWorkers is how many workers a process runs unless configured
+// otherwise.
+const DefaultQueueWorkers = 5
+
+// ErrInvalid is returned when the configuration cannot be loaded or is
+// invalid.
+var ErrInvalid = errors.New("invalid configuration")
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate returns every invalid field at once, joined.
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return fmt.Errorf("config error: %w: %w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+	invalid := func(path string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{path}, args...)...))
+	}
+	required := func(path string, value string) {
+		if value == "" {
+			invalid(path, "is required")
+		}
+	}
+	positive := func(path string, n int64) {
+		if n <= 0 {
+			invalid(path, "must be positive")
+		}
+	}
+	oneOf := func(path string, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		invalid(path, "%q is not one of %q", value, allowed)
+	}
+	absoluteURL := func(path string, value string) {
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid(path, "%q is not an absolute URL", value)
+		}
+	}
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		invalid("http.port", "%q is not a port", c.HTTP.Port)
+	}
+	positive("http.read_timeout", int64(c.HTTP.ReadTimeout))
+	positive("http.write_timeout", int64(c.HTTP.WriteTimeout))
+	positive("http.shutdown_timeout", int64(c.HTTP.ShutdownTimeout))
+
+	required("postgres.host", c.PostgresConfig.Host)
+	required("postgres.port", c.PostgresConfig.Port)
+	required("postgres.db_name", c.PostgresConfig.DbName)
+	required("postgres.user", c.PostgresConfig.User)
+	positive("postgres.pool_max", int64(c.PostgresConfig.PoolMax))
+	positive("postgres.conn_attempts", int64(c.PostgresConfig.ConnAttempts))
+	positive("postgres.conn_timeout", int64(c.PostgresConfig.ConnTimeout))
+
+	oneOf("outbox.publisher", c.Outbox.Publisher, "stdout", "file", "http")
+	if c.Outbox.Publisher == "file" && c.Outbox.FilePath == "" {
+		invalid("outbox.file_path", "is required by the file publisher")
+	}
+	if c.Outbox.Publisher == "http" {
+		absoluteURL("outbox.http_url", c.Outbox.HTTPURL)
+	}
+	positive("outbox.poll_interval", int64(c.Outbox.PollInterval))
+
+	oneOf("alerts.notifier", c.Alerts.Notifier, "log", "webhook")
+	if c.Alerts.Notifier == "webhook" {
+		absoluteURL("alerts.webhook_url", c.Alerts.WebhookURL)
+	}
+
+	if c.Guard.MaxDeviation <= 0 {
+		invalid("guard.max_deviation", "must be positive")
+	}
+	positive("guard.window", int64(c.Guard.Window))
+	positive("guard.min_samples", int64(c.Guard.MinSamples))
+
+	positive("retry.max_attempts", int64(c.Retry.MaxAttempts))
+	positive("retry.base_delay", int64(c.Retry.BaseDelay))
+	if c.Retry.MaxDelay < c.Retry.BaseDelay {
+		invalid("retry.max_delay", "must not be less than retry.base_delay")
+	}
+	positive("retry.poll_interval", int64(c.Retry.PollInterval))
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		invalid("log.level", "%q is not one of %q", c.Log.Level, []string{"debug", "info", "warn", "error"})
+	}
+	oneOf("log.format", c.Log.Format, logger.FormatJSON, logger.FormatText)
+
+	positive("health.check_timeout", int64(c.Health.CheckTimeout))
+	if c.Health.ProviderProbe {
+		positive("health.provider_probe_interval", int64(c.Health.ProviderProbeInterval))
+	}
+	if c.Health.ShutdownDelay < 0 {
+		invalid("health.shutdown_delay", "must not be negative")
+	}
+
+	oneOf("rate_limit.store", c.RateLimit.Store, "memory", "postgres")
+	if _, err := ratelimit.ParseLimit(c.RateLimit.Read, c.RateLimit.ReadBurst); err != nil {
+		invalid("rate_limit.read", "%s", err)
+	}
+	if _, err := ratelimit.ParseLimit(c.RateLimit.Refresh, c.RateLimit.RefreshBurst); err != nil {
+		invalid("rate_limit.refresh", "%s", err)
+	}
+
+	positive("idempotency.ttl", int64(c.Idempotency.TTL))
+
+	if c.HTTPCache.MaxAge < 0 {
+		invalid("http_cache.max_age", "must not be negative")
+	}
+	if c.HTTPCache.Enabled {
+		positive("http_cache.size", int64(c.HTTPCache.Size))
+		positive("http_cache.ttl", int64(c.HTTPCache.TTL))
+	}
+
+	if c.RecordsCache.Enabled {
+		positive("records_cache.size", int64(c.RecordsCache.Size))
+		positive("records_cache.ttl", int64(c.RecordsCache.TTL))
+		positive("records_cache.negative_ttl", int64(c.RecordsCache.NegativeTTL))
+	}
+
+	if c.Queue.Workers < 1 || c.Queue.Workers > MaxQueueWorkers {
+		invalid("queue.workers", "must be between 1 and %d", MaxQueueWorkers)
+	}
+	positive("queue.size", int64(c.Queue.Size))
+	positive("queue.lease", int64(c.Queue.Lease))
+	positive("queue.poll_interval", int64(c.Queue.PollInterval))
+
+	absoluteURL("provider.url", c.Provider.URL)
+	switch {
+	case len(c.Provider.APIKeys) > 0 && c.Provider.APIKeysFile != "":
+		invalid("provider.api_keys", "must not be set along with provider.api_keys_file")
+	case len(c.Provider.APIKeys) == 0 && c.Provider.APIKeysFile == "":
+		invalid("provider.api_keys", "is required, or provider.api_keys_file")
+	case c.Provider.APIKeysFile != "":
+		positive("provider.api_keys_poll_interval", int64(c.Provider.APIKeysPollInterval))
+	}
+	if c.Provider.KeyCooldown < 0 {
+		invalid("provider.key_cooldown", "must not be negative")
+	}
+	positive("provider.timeout", int64(c.Provider.Timeout))
+
+	currencies := func(path string, codes []string) {
+		if len(codes) == 0 {
+			invalid(path, "is required")
+		}
+		for _, code := range codes {
+			if !currencyCode.MatchString(code) {
+				invalid(path, "%q is not a currency code", code)
+			}
+		}
+	}
+	currencies("currencies.base", c.Currencies.Base)
+	currencies("currencies.secondary", c.Currencies.Secondary)
+
+	return errs
+}
@@ -15,79 +15,162 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/exchangerates/latest": {
+        "/admin/apikeys": {
             "get": {
-                "description": "The request specifies the currency pair code.\nIn the response, the service provides the price value and update time.",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "operationId": "list-api-keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.keyResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "The key is returned once and only its hash is stored.",
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "exchangerates"
+                    "admin"
                 ],
-                "summary": "Getting latest exchange rate for currency pair",
-                "operationId": "get-latest-rate",
+                "summary": "Issue an API key",
+                "operationId": "issue-api-key",
                 "parameters": [
                     {
-                        "enum": [
-                            "EUR"
-                        ],
-                        "type": "string",
-                        "description": "first currency code of pair",
-                        "name": "base",
-                        "in": "query",
-                        "required": true
+                        "description": "Client and scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.issueKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v1.issuedKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/apikeys/{id}": {
+            "delete": {
+                "security": [
                     {
-                        "enum": [
-                            "BTC",
-                            "MXN",
-                            "USD",
-                            "BYR",
-                            "AED",
-                            "KZT",
-                            "RUB",
-                            "XAU",
-                            "XAG",
-                            "LYD"
-                        ],
-                        "type": "string",
-                        "description": "second currency code of pair",
-                        "name": "secondary",
-                        "in": "query",
+                        "AdminToken": []
+                    }
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "operationId": "revoke-api-key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/v1.exchangeResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
                         }
                     }
                 }
             }
         },
-        "/exchangerates/refresh": {
+        "/admin/apikeys/{id}/rotate": {
             "post": {
-                "description": "The service assigns an identifier to the update request.\nThe service updates quotes in the background, i.e. the request handler do not perform the update.",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Issues a key with the same client and scopes. The rotated key keeps working for the grace period.",
                 "consumes": [
                     "application/json"
                 ],
@@ -95,132 +178,2136 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "exchangerates"
+                    "admin"
                 ],
-                "summary": "Update exchange rate",
-                "operationId": "update-exchange-rate",
+                "summary": "Rotate an API key",
+                "operationId": "rotate-api-key",
                 "parameters": [
                     {
-                        "description": "Set up currency pair",
+                        "type": "integer",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Grace period of the rotated key, 24h by default",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.doRefreshRequest"
+                            "$ref": "#/definitions/v1.rotateKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/v1.identiferResponse"
+                            "$ref": "#/definitions/v1.issuedKeyResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
                         }
                     }
                 }
             }
         },
-        "/exchangerates/{id}": {
+        "/admin/config/reload": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Loads the configuration file, the environment and the flags again, like SIGHUP. The worker count, the supported currencies and the log level are applied live, other changes require a restart. An invalid configuration is not applied at all.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload the configuration",
+                "operationId": "reload-config",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.reloadResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/failures": {
             "get": {
-                "description": "Display exchange rate value and update time for corresponding identifier request",
-                "consumes": [
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Failed and exhausted records updated within the window, grouped by failure code.",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "exchangerates"
+                    "admin"
                 ],
-                "summary": "Getting rate by identifier",
-                "operationId": "get-rate-by-identifier",
+                "summary": "Recent failures by reason",
+                "operationId": "failures-by-reason",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "unique identifier",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": "24h",
+                        "description": "window, e.g. 1h",
+                        "name": "since",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.exchangeResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.failureCountResponse"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.response"
+                            "$ref": "#/definitions/v1.problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "v1.doRefreshRequest": {
-            "type": "object",
-            "required": [
-                "base",
-                "secondary"
-            ],
-            "properties": {
-                "base": {
-                    "type": "string",
-                    "example": "EUR"
-                },
-                "secondary": {
-                    "type": "string",
-                    "example": "MXN"
-                }
-            }
         },
-        "v1.exchangeResponse": {
-            "type": "object",
-            "properties": {
-                "rate": {
-                    "type": "number"
-                },
-                "update_time": {
-                    "type": "string"
+        "/admin/pipeline": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Queue length, whether the workers are paused, the worker count and the record each worker processes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Show the refresh pipeline",
+                "operationId": "show-pipeline",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.pipelineResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
                 }
             }
         },
-        "v1.identiferResponse": {
-            "type": "object",
-            "properties": {
-                "identifier": {
-                    "type": "string"
+        "/admin/pipeline/pause": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Workers stop taking queued records, records in flight are finished.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Pause the workers",
+                "operationId": "pause-pipeline",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.pipelineResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
                 }
             }
         },
-        "v1.response": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "error message"
-                }
-            }
+        "/admin/pipeline/resume": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Resume the workers",
+                "operationId": "resume-pipeline",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.pipelineResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pipeline/workers": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Stopped workers finish their record in flight first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change the worker count",
+                "operationId": "resize-pipeline",
+                "parameters": [
+                    {
+                        "description": "Worker count",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.resizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.pipelineResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/provider/quota": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Limit and remaining calls as reported by the provider in its latest response, and the calls made since start.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Provider API quota",
+                "operationId": "provider-quota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.quotaResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Records whose provider rate deviated implausibly from recent history wait here for a decision.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List quarantined records",
+                "operationId": "list-quarantined",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.quarantinedResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "The quarantined rate becomes the updated rate of the record.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Accept quarantined rate",
+                "operationId": "accept-quarantined",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "unique identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "The quarantined rate is discarded and the record is failed.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reject quarantined rate",
+                "operationId": "reject-quarantined",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "unique identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/records/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Stores the rates of a CSV file, sent as the file field of a form or as the request body, as updated\nrecords with the imported source. The header names the columns, which are mapped by the query.\nInvalid rows and rows whose pair already has a rate at the same time are skipped and reported by line.",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import historical rates",
+                "operationId": "import-rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "base",
+                        "description": "column of the base currency",
+                        "name": "base_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "secondary",
+                        "description": "column of the secondary currency",
+                        "name": "secondary_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "column of pairs such as EUR/USD, instead of base and secondary",
+                        "name": "pair_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "rate",
+                        "description": "column of the rate",
+                        "name": "rate_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "time",
+                        "description": "column of the time of the rate",
+                        "name": "time_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "02/01/2006",
+                        "description": "Go layout of the time column, RFC 3339 times and dates by default",
+                        "name": "time_layout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": ",",
+                        "description": "field delimiter",
+                        "name": "delimiter",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.importResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/records/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Count records by status",
+                "operationId": "record-stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/records/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Created records, and failed records waiting to be retried, are not processed anymore.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Cancel a pending record",
+                "operationId": "cancel-record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "unique identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "List alert rules",
+                "operationId": "list-alert-rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.alertRuleResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "A \"change\" rule triggers when the rate moves more than threshold percent within the window.\nA \"cross\" rule triggers when the rate crosses the threshold level.\nA triggered rule is silent for the cooldown duration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Create alert rule",
+                "operationId": "create-alert-rule",
+                "parameters": [
+                    {
+                        "description": "Set up alert rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.alertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v1.alertRuleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Get alert rule",
+                "operationId": "get-alert-rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "alert rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.alertRuleResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Updating a rule resets its cooldown and crossing state.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Update alert rule",
+                "operationId": "update-alert-rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "alert rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Set up alert rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.alertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.alertRuleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delete alert rule",
+                "operationId": "delete-alert-rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "alert rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Streams the records matching the filters, oldest first, as CSV with a header row or as\nnewline delimited JSON, depending on the Accept header.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Export exchange rate history",
+                "operationId": "export-exchange-rates",
+                "parameters": [
+                    {
+                        "enum": [
+                            "EUR"
+                        ],
+                        "type": "string",
+                        "description": "first currency code of pair",
+                        "name": "base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "second currency code of pair",
+                        "name": "secondary",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "updated,quarantined",
+                        "description": "comma separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-03-01",
+                        "description": "records updated at or after, RFC 3339 or date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-04-01T00:00:00Z",
+                        "description": "records updated before, RFC 3339 or date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "base,secondary,rate,updated_at",
+                        "description": "comma separated columns, identifier, base, secondary, rate, status and updated_at by default",
+                        "name": "columns",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/latest": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "The request specifies the currency pair code.\nIn the response, the service provides the price value and update time.\nResponses carry an ETag and Last-Modified for conditional requests.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Getting latest exchange rate for currency pair",
+                "operationId": "get-latest-rate",
+                "parameters": [
+                    {
+                        "enum": [
+                            "EUR"
+                        ],
+                        "type": "string",
+                        "description": "first currency code of pair",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "BTC",
+                            "MXN",
+                            "USD",
+                            "BYR",
+                            "AED",
+                            "KZT",
+                            "RUB",
+                            "XAU",
+                            "XAG",
+                            "LYD"
+                        ],
+                        "type": "string",
+                        "description": "second currency code of pair",
+                        "name": "secondary",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the cached response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the cached response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.exchangeResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "rate has not changed"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/latest:batch": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "The request lists the currency pairs, the response has an item per pair in the same order.\nPairs that are invalid or have no rate yet carry an error instead of the rate.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Getting latest exchange rates of many pairs",
+                "operationId": "get-latest-rate-batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "EUR/USD,EUR/MXN",
+                        "description": "comma separated pairs, up to 20",
+                        "name": "pairs",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.latestBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "The service assigns an identifier to the update request.\nThe service updates quotes in the background, i.e. the request handler do not perform the update.\nRetries with the same Idempotency-Key return the identifier of the first request, marked by the\nIdempotent-Replayed header; reusing a key for a different pair fails with 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Update exchange rate",
+                "operationId": "update-exchange-rate",
+                "parameters": [
+                    {
+                        "description": "Set up currency pair",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.doRefreshRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, e.g. a UUID",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v1.identiferResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/refresh:batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "The service assigns an identifier to every valid pair and updates them in the background.\nInvalid or duplicate pairs carry an error instead of an identifier, the other pairs are still queued.\nA batch counts as a single request against the rate limit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Update exchange rates of many pairs",
+                "operationId": "update-exchange-rate-batch",
+                "parameters": [
+                    {
+                        "description": "Up to 20 currency pairs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.doRefreshBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.refreshBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Display exchange rate value and update time for corresponding identifier request\nFailed requests carry a machine-readable failure code and the provider error message\nResponses carry an ETag and Last-Modified for conditional requests. Updated and cancelled records\nnever change again and are marked immutable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Getting rate by identifier",
+                "operationId": "get-rate-by-identifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "unique identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the cached response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the cached response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.recordResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "record has not changed"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchangerates/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Queues a failed or exhausted update request again, regardless of its remaining attempt budget.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchangerates"
+                ],
+                "summary": "Retry failed exchange rate update",
+                "operationId": "retry-exchange-rate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "unique identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/v1.identiferResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "v1.alertRuleRequest": {
+            "type": "object",
+            "required": [
+                "base",
+                "kind",
+                "secondary",
+                "threshold"
+            ],
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "cooldown": {
+                    "type": "string",
+                    "example": "30m"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "change",
+                        "cross"
+                    ],
+                    "example": "change"
+                },
+                "secondary": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "window": {
+                    "type": "string",
+                    "example": "1h"
+                }
+            }
+        },
+        "v1.alertRuleResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string"
+                },
+                "cooldown": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_triggered_at": {
+                    "type": "string"
+                },
+                "secondary": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.doRefreshBatchRequest": {
+            "type": "object",
+            "required": [
+                "pairs"
+            ],
+            "properties": {
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.doRefreshRequest"
+                    }
+                }
+            }
+        },
+        "v1.doRefreshRequest": {
+            "type": "object",
+            "required": [
+                "base",
+                "secondary"
+            ],
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "secondary": {
+                    "type": "string",
+                    "example": "MXN"
+                }
+            }
+        },
+        "v1.exchangeResponse": {
+            "type": "object",
+            "properties": {
+                "rate": {
+                    "type": "number"
+                },
+                "update_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.failureCountResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "last_at": {
+                    "type": "string"
+                },
+                "last_message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.fieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "pairs[0].base"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
+        "v1.identiferResponse": {
+            "type": "object",
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.importResponse": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.importRowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.importRowError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "unsupported_secondary_currency"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "not supported secondary currency"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "v1.inFlightResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "secondary": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "worker": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.issueKeyRequest": {
+            "type": "object",
+            "required": [
+                "client",
+                "scopes"
+            ],
+            "properties": {
+                "client": {
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "expires_in": {
+                    "type": "string",
+                    "example": "720h"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "rates:read",
+                            "rates:refresh",
+                            "alerts",
+                            "admin"
+                        ]
+                    },
+                    "example": [
+                        "rates:read",
+                        "rates:refresh"
+                    ]
+                }
+            }
+        },
+        "v1.issuedKeyResponse": {
+            "type": "object",
+            "properties": {
+                "client": {
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "Key is only returned once, when it is issued.",
+                    "type": "string",
+                    "example": "erk_Zk3x9aQ1..."
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "erk_Zk3x9aQ1"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "rates:read"
+                    ]
+                }
+            }
+        },
+        "v1.keyResponse": {
+            "type": "object",
+            "properties": {
+                "client": {
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "erk_Zk3x9aQ1"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "rates:read"
+                    ]
+                }
+            }
+        },
+        "v1.latestBatchItem": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "code": {
+                    "type": "string",
+                    "example": "record_not_found"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "exchange rate was not found"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "secondary": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "update_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.latestBatchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.latestBatchItem"
+                    }
+                }
+            }
+        },
+        "v1.pipelineResponse": {
+            "type": "object",
+            "properties": {
+                "in_flight": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.inFlightResponse"
+                    }
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "queue_length": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "record_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "exchange rate was not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.fieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/v1/exchangerates/latest"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "5b7c8e0e-3f0e-4a43-9a4c-1b6f3f0a2c11"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "v1.quarantinedResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "secondary": {
+                    "type": "string"
+                },
+                "update_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.quotaResponse": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.recordResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "billing-service"
+                },
+                "failure_code": {
+                    "type": "string",
+                    "example": "quota_exhausted"
+                },
+                "failure_message": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "failed"
+                },
+                "update_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.refreshBatchItem": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "code": {
+                    "type": "string",
+                    "example": "unsupported_secondary_currency"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "not supported secondary currency"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "secondary": {
+                    "type": "string",
+                    "example": "MXN"
+                }
+            }
+        },
+        "v1.refreshBatchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.refreshBatchItem"
+                    }
+                }
+            }
+        },
+        "v1.reloadResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "queue.workers",
+                        "log.level"
+                    ]
+                },
+                "restart_required": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "http.port"
+                    ]
+                }
+            }
+        },
+        "v1.resizeRequest": {
+            "type": "object",
+            "required": [
+                "count"
+            ],
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "v1.rotateKeyRequest": {
+            "type": "object",
+            "properties": {
+                "grace": {
+                    "type": "string",
+                    "example": "24h"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ApiKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,254 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+type Alerter struct {
+	repo     Repo
+	history  History
+	notifier Notifier
+	logger   *slog.Logger
+}
+
+type Option func(*Alerter)
+
+func WithLogger(l *slog.Logger) Option {
+	return func(a *Alerter) {
+		a.logger = l
+	}
+}
+
+func NewService(repo Repo, history History, notifier Notifier, opts ...Option) *Alerter {
+	a := &Alerter{
+		repo:     repo,
+		history:  history,
+		notifier: notifier,
+		logger:   slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+func (a *Alerter) Create(ctx context.Context, rule *Rule) error {
+	if err := validRule(rule); err != nil {
+		return err
+	}
+
+	if err := a.repo.Insert(ctx, rule); err != nil {
+		return fmt.Errorf("creating alert rule is failed: %w", err)
+	}
+	return nil
+}
+
+func (a *Alerter) Fetch(ctx context.Context, id int) (*Rule, error) {
+	rule, err := a.repo.Fetch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetching alert rule is failed: %w", err)
+	}
+	return rule, nil
+}
+
+func (a *Alerter) List(ctx context.Context) ([]Rule, error) {
+	rules, err := a.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing alert rules is failed: %w", err)
+	}
+	return rules, nil
+}
+
+func (a *Alerter) Update(ctx context.Context, rule *Rule) error {
+	if err := validRule(rule); err != nil {
+		return err
+	}
+
+	if err := a.repo.Update(ctx, rule); err != nil {
+		return fmt.Errorf("updating alert rule is failed: %w", err)
+	}
+	return nil
+}
+
+func (a *Alerter) Delete(ctx context.Context, id int) error {
+	if err := a.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting alert rule is failed: %w", err)
+	}
+	return nil
+}
+
+// OnUpdated evaluates the rules of the record's pair against its new rate.
+func (a *Alerter) OnUpdated(ctx context.Context, record *exchangerates.Record) {
+	rules, err := a.repo.ListForPair(ctx, record.Base, record.Secondary)
+	if err != nil {
+		// NoReturnErr: alerting must not fail the record update
+		a.logger.ErrorContext(ctx, "alerts: listing rules error",
+			logger.Err(err), slog.String("identifier", record.Identifier))
+		return
+	}
+
+	var history []exchangerates.Record
+	if window := maxWindow(rules); window > 0 {
+		history, err = a.history.FetchHistory(ctx, record.Base, record.Secondary, record.Updated_At.Add(-window))
+		if err != nil {
+			// NoReturnErr: change rules are skipped for this update
+			a.logger.ErrorContext(ctx, "alerts: fetching history error",
+				logger.Err(err), slog.String("identifier", record.Identifier))
+		}
+	}
+
+	for i := range rules {
+		a.evaluate(ctx, &rules[i], record, history)
+	}
+}
+
+func (a *Alerter) evaluate(ctx context.Context, rule *Rule, record *exchangerates.Record, history []exchangerates.Record) {
+	var (
+		triggered bool
+		reference float64
+	)
+
+	switch rule.Kind {
+	case KindChange:
+		triggered, reference = changed(rule, record, history)
+	case KindCross:
+		triggered, reference = crossed(rule, record)
+		if err := a.repo.SetLastValue(ctx, rule.Id, record.Rate); err != nil {
+			// NoReturnErr: the crossing is detected against an older value next time
+			a.logger.ErrorContext(ctx, "alerts: storing last value error",
+				logger.Err(err), slog.Int("rule_id", rule.Id))
+		}
+	}
+
+	if !triggered {
+		return
+	}
+
+	now := time.Now()
+	ok, err := a.repo.MarkTriggered(ctx, rule.Id, now)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "alerts: marking rule triggered error",
+			logger.Err(err), slog.Int("rule_id", rule.Id))
+		return
+	}
+
+	if !ok {
+		// NoReturnErr: rule is cooling down
+		return
+	}
+
+	alert := Alert{
+		RuleId:       rule.Id,
+		Kind:         rule.Kind,
+		Base:         record.Base,
+		Secondary:    record.Secondary,
+		Threshold:    rule.Threshold,
+		Rate:         record.Rate,
+		Reference:    reference,
+		Identifier:   record.Identifier,
+		Message:      message(rule, record.Rate, reference),
+		Triggered_At: now,
+	}
+
+	if err := a.notifier.Notify(ctx, alert); err != nil {
+		a.logger.ErrorContext(ctx, "alerts: notifying error",
+			logger.Err(err), slog.Int("rule_id", rule.Id))
+	}
+}
+
+// changed reports whether the rate moved more than the rule threshold, in
+// percent, against any rate within the rule window, and returns that rate.
+func changed(rule *Rule, record *exchangerates.Record, history []exchangerates.Record) (bool, float64) {
+	since := record.Updated_At.Add(-rule.Window)
+
+	var (
+		maxChange float64
+		reference float64
+	)
+	for _, h := range history {
+		if h.Identifier == record.Identifier || h.Updated_At.Before(since) || h.Rate == 0 {
+			continue
+		}
+
+		change := math.Abs(record.Rate-h.Rate) / h.Rate * 100
+		if change > maxChange {
+			maxChange = change
+			reference = h.Rate
+		}
+	}
+
+	return maxChange > rule.Threshold, reference
+}
+
+// crossed reports whether the rate crossed the rule level since the previous
+// evaluation, and returns the previous rate.
+func crossed(rule *Rule, record *exchangerates.Record) (bool, float64) {
+	if rule.LastValue == nil {
+		return false, 0
+	}
+
+	last := *rule.LastValue
+	up := last < rule.Threshold && record.Rate >= rule.Threshold
+	down := last > rule.Threshold && record.Rate <= rule.Threshold
+
+	return up || down, last
+}
+
+func message(rule *Rule, rate float64, reference float64) string {
+	pair := rule.Base + "/" + rule.Secondary
+
+	if rule.Kind == KindChange {
+		return fmt.Sprintf("%s moved from %g to %g, more than %g%% within %s", pair, reference, rate, rule.Threshold, rule.Window)
+	}
+	return fmt.Sprintf("%s crossed %g: from %g to %g", pair, rule.Threshold, reference, rate)
+}
+
+func maxWindow(rules []Rule) time.Duration {
+	var window time.Duration
+	for _, rule := range rules {
+		if rule.Kind == KindChange && rule.Window > window {
+			window = rule.Window
+		}
+	}
+	return window
+}
+
+func validRule(rule *Rule) error {
+	rule.Base = strings.ToUpper(strings.TrimSpace(rule.Base))
+	rule.Secondary = strings.ToUpper(strings.TrimSpace(rule.Secondary))
+
+	if err := exchangerates.ValidatePair(rule.Base, rule.Secondary); err != nil {
+		return err
+	}
+
+	switch rule.Kind {
+	case KindChange:
+		if rule.Window <= 0 {
+			return ErrInvalidWindow
+		}
+	case KindCross:
+		rule.Window = 0
+	default:
+		return ErrInvalidKind
+	}
+
+	if rule.Threshold <= 0 {
+		return ErrInvalidThreshold
+	}
+
+	if rule.Cooldown < 0 {
+		return ErrInvalidCooldown
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRepo struct {
+	Repo
+	rules []Rule
+}
+
+func (r *memoryRepo) ListForPair(_ context.Context, _ string, _ string) ([]Rule, error) {
+	return append([]Rule(nil), r.rules...), nil
+}
+
+func (r *memoryRepo) SetLastValue(_ context.Context, id int, value float64) error {
+	for i := range r.rules {
+		if r.rules[i].Id == id {
+			r.rules[i].LastValue = &value
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) MarkTriggered(_ context.Context, id int, at time.Time) (bool, error) {
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.Id != id {
+			continue
+		}
+		if rule.LastTriggered_At != nil && rule.LastTriggered_At.Add(rule.Cooldown).After(at) {
+			return false, nil
+		}
+		rule.LastTriggered_At = &at
+		return true, nil
+	}
+	return false, nil
+}
+
+type memoryHistory []exchangerates.Record
+
+func (h memoryHistory) FetchHistory(_ context.Context, _ string, _ string, _ time.Time) ([]exchangerates.Record, error) {
+	return h, nil
+}
+
+type memoryNotifier []Alert
+
+func (n *memoryNotifier) Notify(_ context.Context, alert Alert) error {
+	*n = append(*n, alert)
+	return nil
+}
+
+func TestAlerter_OnUpdated_Change(t *testing.T) {
+	now := time.Now()
+	history := memoryHistory{
+		{Identifier: "old", Rate: 1.0000, Updated_At: now.Add(-2 * time.Hour)},
+		{Identifier: "recent", Rate: 1.0800, Updated_At: now.Add(-30 * time.Minute)},
+	}
+	repo := &memoryRepo{rules: []Rule{
+		{Id: 1, Kind: KindChange, Threshold: 0.5, Window: time.Hour, Cooldown: time.Hour},
+	}}
+	notifier := &memoryNotifier{}
+	a := NewService(repo, history, notifier)
+
+	// 0.4% within the window does not trigger
+	a.OnUpdated(context.Background(), &exchangerates.Record{Identifier: "a", Rate: 1.0843, Updated_At: now})
+	require.Empty(t, *notifier)
+
+	// 0.6% within the window triggers once, then cools down
+	a.OnUpdated(context.Background(), &exchangerates.Record{Identifier: "b", Rate: 1.0865, Updated_At: now})
+	a.OnUpdated(context.Background(), &exchangerates.Record{Identifier: "c", Rate: 1.0870, Updated_At: now})
+	require.Len(t, *notifier, 1)
+	require.Equal(t, 1.08, (*notifier)[0].Reference)
+}
+
+func TestAlerter_OnUpdated_Cross(t *testing.T) {
+	repo := &memoryRepo{rules: []Rule{
+		{Id: 1, Kind: KindCross, Threshold: 2000},
+	}}
+	notifier := &memoryNotifier{}
+	a := NewService(repo, memoryHistory{}, notifier)
+
+	for _, rate := range []float64{1990, 1995, 2001, 2005, 1999} {
+		a.OnUpdated(context.Background(), &exchangerates.Record{Rate: rate, Updated_At: time.Now()})
+	}
+
+	require.Len(t, *notifier, 2)
+	require.Equal(t, 2001.0, (*notifier)[0].Rate)
+	require.Equal(t, 1999.0, (*notifier)[1].Rate)
+}
+
+func TestValidRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        Rule
+		expectedErr error
+	}{
+		{"valid change", Rule{Base: "eur", Secondary: "usd", Kind: KindChange, Threshold: 0.5, Window: time.Hour}, nil},
+		{"valid cross", Rule{Base: "EUR", Secondary: "XAU", Kind: KindCross, Threshold: 2000}, nil},
+		{"unsupported pair", Rule{Base: "USD", Secondary: "EUR", Kind: KindCross, Threshold: 1}, exchangerates.ErrNotSupportedBaseCurrency},
+		{"unknown kind", Rule{Base: "EUR", Secondary: "USD", Kind: "spike", Threshold: 1}, ErrInvalidKind},
+		{"change without window", Rule{Base: "EUR", Secondary: "USD", Kind: KindChange, Threshold: 1}, ErrInvalidWindow},
+		{"zero threshold", Rule{Base: "EUR", Secondary: "USD", Kind: KindCross}, ErrInvalidThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, validRule(&tt.rule), tt.expectedErr)
+		})
+	}
+}
+
+type blockingNotifier chan Alert
+
+func (n blockingNotifier) Notify(_ context.Context, alert Alert) error {
+	n <- alert
+	return nil
+}
+
+func TestDispatcher_OnUpdated(t *testing.T) {
+	repo := &memoryRepo{rules: []Rule{
+		{Id: 1, Kind: KindCross, Threshold: 1.5, LastValue: new(float64)},
+	}}
+	notifier := make(blockingNotifier)
+	d := NewDispatcher(NewService(repo, memoryHistory{}, notifier), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.Start(ctx)
+
+	// Updates return at once while the notifier blocks, and are dropped once
+	// the queue is full
+	d.OnUpdated(ctx, &exchangerates.Record{Identifier: "a", Rate: 2})
+	require.Eventually(t, func() bool { return len(d.updates) == 0 }, time.Second, time.Millisecond)
+	d.OnUpdated(ctx, &exchangerates.Record{Identifier: "b", Rate: 1})
+	d.OnUpdated(ctx, &exchangerates.Record{Identifier: "c", Rate: 2})
+
+	require.Equal(t, "a", (<-notifier).Identifier)
+	require.Equal(t, "b", (<-notifier).Identifier)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,60 @@
+package alerts
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+)
+
+type update struct {
+	ctx    context.Context
+	record *exchangerates.Record
+}
+
+// Dispatcher evaluates the rules of updated records in the background, in
+// order, so that a slow notifier does not hold up the workers. Updates are
+// dropped while its queue is full.
+type Dispatcher struct {
+	alerter *Alerter
+	updates chan update
+}
+
+func NewDispatcher(a *Alerter, size int) *Dispatcher {
+	return &Dispatcher{
+		alerter: a,
+		updates: make(chan update, size),
+	}
+}
+
+// OnUpdated queues the record for Alerter.OnUpdated.
+func (d *Dispatcher) OnUpdated(ctx context.Context, record *exchangerates.Record) {
+	select {
+	case d.updates <- update{context.WithoutCancel(ctx), record}:
+	default:
+		// NoReturnErr: alerting must not hold up the record update
+		d.alerter.logger.WarnContext(ctx, "alerts: dispatcher is full, update dropped",
+			slog.String("identifier", record.Identifier))
+	}
+}
+
+// Start evaluates queued updates until ctx is cancelled. The returned channel
+// is closed once it has stopped; updates still queued are not evaluated.
+func (d *Dispatcher) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u := <-d.updates:
+				d.alerter.OnUpdated(u.ctx, u.record)
+			}
+		}
+	}()
+
+	return done
+}
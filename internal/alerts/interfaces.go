@@ -0,0 +1,38 @@
+package alerts
+
+import (
+	"context"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+)
+
+type Service interface {
+	Create(context.Context, *Rule) error
+	Fetch(context.Context, int) (*Rule, error)
+	List(context.Context) ([]Rule, error)
+	Update(context.Context, *Rule) error
+	Delete(context.Context, int) error
+}
+
+type Repo interface {
+	Insert(context.Context, *Rule) error
+	Fetch(context.Context, int) (*Rule, error)
+	List(context.Context) ([]Rule, error)
+	ListForPair(ctx context.Context, base string, secondary string) ([]Rule, error)
+	Update(context.Context, *Rule) error
+	Delete(context.Context, int) error
+	SetLastValue(ctx context.Context, id int, value float64) error
+	// MarkTriggered records the trigger time unless the rule is still cooling
+	// down, and reports whether it did.
+	MarkTriggered(ctx context.Context, id int, at time.Time) (bool, error)
+}
+
+// History provides recent updated rates of a pair.
+type History interface {
+	FetchHistory(ctx context.Context, base string, secondary string, since time.Time) ([]exchangerates.Record, error)
+}
+
+type Notifier interface {
+	Notify(context.Context, Alert) error
+}
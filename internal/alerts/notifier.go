@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+)
+
+type logNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(l *slog.Logger) Notifier {
+	return logNotifier{logger: l}
+}
+
+func (n logNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.logger.InfoContext(ctx, "alert triggered",
+		slog.Int("rule_id", alert.RuleId),
+		slog.String("kind", string(alert.Kind)),
+		slog.String("message", alert.Message))
+	return nil
+}
+
+// webhookNotifier POSTs every alert as JSON to a fixed endpoint.
+type webhookNotifier struct {
+	xcl *httpx.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{
+		xcl: httpx.NewClient("AlertsWebhook", url, httpx.WithDefaultHTTPClientWithTimeout(timeout)),
+	}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	return n.xcl.Do(ctx, httpx.Call{
+		Method:  http.MethodPost,
+		Request: alert,
+	})
+}
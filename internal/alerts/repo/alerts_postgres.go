@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const _ruleColumns = "id, base, secondary, kind, threshold, window_seconds, cooldown_seconds, last_value, last_triggered_at, created_at, updated_at"
+
+type alertsRepository struct {
+	connPool *pgxpool.Pool
+}
+
+func NewAlertsRepository(connPool *pgxpool.Pool) (*alertsRepository, error) {
+
+	if connPool == nil {
+		return nil, errors.New("provided connPool handle is nil")
+	}
+
+	return &alertsRepository{connPool}, nil
+}
+
+func (r *alertsRepository) Insert(ctx context.Context, rule *alerts.Rule) error {
+	current_time := time.Now()
+
+	err := r.connPool.QueryRow(ctx, "INSERT INTO alert_rules (base, secondary, kind, threshold, window_seconds, cooldown_seconds, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id",
+		rule.Base, rule.Secondary, rule.Kind, rule.Threshold, seconds(rule.Window), seconds(rule.Cooldown), current_time, current_time).Scan(&rule.Id)
+	if err != nil {
+		return err
+	}
+
+	rule.Created_At = current_time
+	rule.Updated_At = current_time
+
+	return nil
+}
+
+func (r *alertsRepository) Fetch(ctx context.Context, id int) (*alerts.Rule, error) {
+	row := r.connPool.QueryRow(ctx, "SELECT "+_ruleColumns+" FROM alert_rules WHERE id = $1", id)
+
+	rule, err := scanRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, alerts.ErrNoRule
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (r *alertsRepository) List(ctx context.Context) ([]alerts.Rule, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT "+_ruleColumns+" FROM alert_rules ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (alerts.Rule, error) {
+		return scanRule(row)
+	})
+}
+
+func (r *alertsRepository) ListForPair(ctx context.Context, base string, secondary string) ([]alerts.Rule, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT "+_ruleColumns+" FROM alert_rules WHERE base = $1 AND secondary = $2 ORDER BY id", base, secondary)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (alerts.Rule, error) {
+		return scanRule(row)
+	})
+}
+
+func (r *alertsRepository) Update(ctx context.Context, rule *alerts.Rule) error {
+	current_time := time.Now()
+
+	// Changing the rule resets its crossing and cooldown state
+	row := r.connPool.QueryRow(ctx, "UPDATE alert_rules SET base = $1, secondary = $2, kind = $3, threshold = $4, window_seconds = $5, cooldown_seconds = $6, last_value = NULL, last_triggered_at = NULL, updated_at = $7 WHERE id = $8 RETURNING "+_ruleColumns,
+		rule.Base, rule.Secondary, rule.Kind, rule.Threshold, seconds(rule.Window), seconds(rule.Cooldown), current_time, rule.Id)
+
+	updated, err := scanRule(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return alerts.ErrNoRule
+		}
+		return err
+	}
+
+	*rule = updated
+	return nil
+}
+
+func (r *alertsRepository) Delete(ctx context.Context, id int) error {
+	tag, err := r.connPool.Exec(ctx, "DELETE FROM alert_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return alerts.ErrNoRule
+	}
+	return nil
+}
+
+func (r *alertsRepository) SetLastValue(ctx context.Context, id int, value float64) error {
+	_, err := r.connPool.Exec(ctx, "UPDATE alert_rules SET last_value = $1 WHERE id = $2", value, id)
+	return err
+}
+
+func (r *alertsRepository) MarkTriggered(ctx context.Context, id int, at time.Time) (bool, error) {
+	tag, err := r.connPool.Exec(ctx, "UPDATE alert_rules SET last_triggered_at = $1 WHERE id = $2 AND (last_triggered_at IS NULL OR last_triggered_at + cooldown_seconds * INTERVAL '1 second' <= $1)",
+		at, id)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func scanRule(row pgx.Row) (alerts.Rule, error) {
+	var (
+		rule                    alerts.Rule
+		windowSecs, cooldownSec int64
+	)
+
+	err := row.Scan(&rule.Id, &rule.Base, &rule.Secondary, &rule.Kind, &rule.Threshold, &windowSecs, &cooldownSec,
+		&rule.LastValue, &rule.LastTriggered_At, &rule.Created_At, &rule.Updated_At)
+
+	rule.Window = time.Duration(windowSecs) * time.Second
+	rule.Cooldown = time.Duration(cooldownSec) * time.Second
+
+	return rule, err
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
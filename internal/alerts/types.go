@@ -0,0 +1,52 @@
+package alerts
+
+import (
+	"errors"
+	"time"
+)
+
+type RuleKind string
+
+const (
+	// KindChange triggers when the rate moved more than Threshold percent
+	// against any rate observed within Window.
+	KindChange RuleKind = "change"
+	// KindCross triggers when the rate crosses the Threshold level in either direction.
+	KindCross RuleKind = "cross"
+)
+
+type Rule struct {
+	Id               int
+	Base             string
+	Secondary        string
+	Kind             RuleKind
+	Threshold        float64
+	Window           time.Duration
+	Cooldown         time.Duration
+	LastValue        *float64
+	LastTriggered_At *time.Time
+	Created_At       time.Time
+	Updated_At       time.Time
+}
+
+// Alert is handed to notifiers when a rule triggers.
+type Alert struct {
+	RuleId       int       `json:"rule_id"`
+	Kind         RuleKind  `json:"kind"`
+	Base         string    `json:"base"`
+	Secondary    string    `json:"secondary"`
+	Threshold    float64   `json:"threshold"`
+	Rate         float64   `json:"rate"`
+	Reference    float64   `json:"reference"`
+	Identifier   string    `json:"identifier"`
+	Message      string    `json:"message"`
+	Triggered_At time.Time `json:"triggered_at"`
+}
+
+var (
+	ErrNoRule           = errors.New("no alert rule was found")
+	ErrInvalidKind      = errors.New("invalid alert rule kind")
+	ErrInvalidThreshold = errors.New("alert rule threshold must be positive")
+	ErrInvalidWindow    = errors.New("change alert rule requires a positive window")
+	ErrInvalidCooldown  = errors.New("alert rule cooldown must not be negative")
+)
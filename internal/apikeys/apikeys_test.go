@@ -0,0 +1,136 @@
+package apikeys_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRepo struct {
+	mu   sync.Mutex
+	keys []apikeys.Key
+}
+
+func (r *memoryRepo) Insert(_ context.Context, key *apikeys.Key) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.Id = len(r.keys) + 1
+	key.Created_At = time.Now()
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memoryRepo) FetchByHash(_ context.Context, hash string) (*apikeys.Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, apikeys.ErrNoKey
+}
+
+func (r *memoryRepo) List(context.Context) ([]apikeys.Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]apikeys.Key(nil), r.keys...), nil
+}
+
+func (r *memoryRepo) Rotate(ctx context.Context, id int, next *apikeys.Key, retireAt time.Time) error {
+	r.mu.Lock()
+	if id < 1 || id > len(r.keys) {
+		r.mu.Unlock()
+		return apikeys.ErrNoKey
+	}
+	current := &r.keys[id-1]
+	current.Expires_At = &retireAt
+	next.Client = current.Client
+	next.Scopes = current.Scopes
+	r.mu.Unlock()
+
+	return r.Insert(ctx, next)
+}
+
+func (r *memoryRepo) Revoke(_ context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > len(r.keys) {
+		return apikeys.ErrNoKey
+	}
+	r.keys[id-1].Revoked_At = &at
+	return nil
+}
+
+func TestKeys_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	k := apikeys.NewService(&memoryRepo{})
+
+	key, secret, err := k.Issue(ctx, " billing ", []apikeys.Scope{apikeys.ScopeRead}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "billing", key.Client)
+	require.NotContains(t, key.Hash, secret)
+	require.True(t, len(secret) > len(key.Prefix))
+	require.Equal(t, secret[:len(key.Prefix)], key.Prefix)
+
+	authenticated, err := k.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	require.Equal(t, key.Id, authenticated.Id)
+	require.True(t, authenticated.HasScope(apikeys.ScopeRead))
+	require.False(t, authenticated.HasScope(apikeys.ScopeRefresh))
+
+	_, err = k.Authenticate(ctx, secret+"x")
+	require.ErrorIs(t, err, apikeys.ErrInvalidKey)
+
+	require.NoError(t, k.Revoke(ctx, key.Id))
+	_, err = k.Authenticate(ctx, secret)
+	require.ErrorIs(t, err, apikeys.ErrInvalidKey)
+}
+
+func TestKeys_Rotate(t *testing.T) {
+	ctx := context.Background()
+	k := apikeys.NewService(&memoryRepo{})
+
+	key, secret, err := k.Issue(ctx, "billing", []apikeys.Scope{apikeys.ScopeRefresh}, nil)
+	require.NoError(t, err)
+
+	next, nextSecret, err := k.Rotate(ctx, key.Id, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, nextSecret)
+	require.Equal(t, "billing", next.Client)
+	require.Equal(t, []apikeys.Scope{apikeys.ScopeRefresh}, next.Scopes)
+
+	// Without grace period the rotated key stops working immediately
+	_, err = k.Authenticate(ctx, secret)
+	require.ErrorIs(t, err, apikeys.ErrInvalidKey)
+
+	_, err = k.Authenticate(ctx, nextSecret)
+	require.NoError(t, err)
+}
+
+func TestKeys_IssueValidation(t *testing.T) {
+	k := apikeys.NewService(&memoryRepo{})
+
+	_, _, err := k.Issue(context.Background(), "", []apikeys.Scope{apikeys.ScopeRead}, nil)
+	require.ErrorIs(t, err, apikeys.ErrInvalidClient)
+
+	_, _, err = k.Issue(context.Background(), "billing", nil, nil)
+	require.ErrorIs(t, err, apikeys.ErrInvalidScope)
+
+	_, _, err = k.Issue(context.Background(), "billing", []apikeys.Scope{"rates:write"}, nil)
+	require.ErrorIs(t, err, apikeys.ErrInvalidScope)
+}
+
+func TestKey_AdminScope(t *testing.T) {
+	key := apikeys.Key{Scopes: []apikeys.Scope{apikeys.ScopeAdmin}}
+	require.True(t, key.HasScope(apikeys.ScopeRefresh))
+	require.True(t, key.HasScope(apikeys.ScopeAlerts))
+}
@@ -0,0 +1,29 @@
+package apikeys
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	// Issue creates a key and returns it along with the secret, which is not
+	// stored and cannot be recovered.
+	Issue(ctx context.Context, client string, scopes []Scope, expiresAt *time.Time) (*Key, string, error)
+	// Authenticate resolves a secret to an active key, or returns ErrInvalidKey.
+	Authenticate(ctx context.Context, secret string) (*Key, error)
+	List(context.Context) ([]Key, error)
+	// Rotate issues a key with the client and scopes of the given one, which
+	// keeps working for the grace period.
+	Rotate(ctx context.Context, id int, grace time.Duration) (*Key, string, error)
+	Revoke(context.Context, int) error
+}
+
+type Repo interface {
+	Insert(context.Context, *Key) error
+	FetchByHash(context.Context, string) (*Key, error)
+	List(context.Context) ([]Key, error)
+	// Rotate inserts next with the client and scopes of the active key id,
+	// and expires that key at retireAt unless it expires earlier.
+	Rotate(ctx context.Context, id int, next *Key, retireAt time.Time) error
+	Revoke(ctx context.Context, id int, at time.Time) error
+}
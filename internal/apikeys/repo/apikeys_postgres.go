@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const _keyColumns = "id, client, prefix, key_hash, scopes, created_at, expires_at, revoked_at"
+
+type apiKeysRepository struct {
+	connPool *pgxpool.Pool
+}
+
+func NewAPIKeysRepository(connPool *pgxpool.Pool) (*apiKeysRepository, error) {
+
+	if connPool == nil {
+		return nil, errors.New("provided connPool handle is nil")
+	}
+
+	return &apiKeysRepository{connPool}, nil
+}
+
+func (r *apiKeysRepository) Insert(ctx context.Context, key *apikeys.Key) error {
+	return insertKey(ctx, r.connPool, key)
+}
+
+func (r *apiKeysRepository) FetchByHash(ctx context.Context, hash string) (*apikeys.Key, error) {
+	key, err := scanKey(r.connPool.QueryRow(ctx, "SELECT "+_keyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apikeys.ErrNoKey
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeysRepository) List(ctx context.Context) ([]apikeys.Key, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT "+_keyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (apikeys.Key, error) {
+		return scanKey(row)
+	})
+}
+
+func (r *apiKeysRepository) Rotate(ctx context.Context, id int, next *apikeys.Key, retireAt time.Time) error {
+	return pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		current, err := scanKey(tx.QueryRow(ctx, "SELECT "+_keyColumns+" FROM api_keys WHERE id = $1 AND revoked_at IS NULL FOR UPDATE", id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apikeys.ErrNoKey
+			}
+			return err
+		}
+
+		if current.Expires_At == nil || current.Expires_At.After(retireAt) {
+			if _, err := tx.Exec(ctx, "UPDATE api_keys SET expires_at = $1 WHERE id = $2", retireAt, id); err != nil {
+				return err
+			}
+		}
+
+		next.Client = current.Client
+		next.Scopes = current.Scopes
+		return insertKey(ctx, tx, next)
+	})
+}
+
+func (r *apiKeysRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	tag, err := r.connPool.Exec(ctx, "UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apikeys.ErrNoKey
+	}
+	return nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertKey(ctx context.Context, q querier, key *apikeys.Key) error {
+	current_time := time.Now()
+
+	err := q.QueryRow(ctx, "INSERT INTO api_keys (client, prefix, key_hash, scopes, created_at, expires_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id",
+		key.Client, key.Prefix, key.Hash, scopeStrings(key.Scopes), current_time, key.Expires_At).Scan(&key.Id)
+	if err != nil {
+		return err
+	}
+
+	key.Created_At = current_time
+	return nil
+}
+
+func scanKey(row pgx.Row) (apikeys.Key, error) {
+	var (
+		key    apikeys.Key
+		scopes []string
+	)
+
+	err := row.Scan(&key.Id, &key.Client, &key.Prefix, &key.Hash, &scopes,
+		&key.Created_At, &key.Expires_At, &key.Revoked_At)
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, apikeys.Scope(scope))
+	}
+
+	return key, err
+}
+
+func scopeStrings(scopes []apikeys.Scope) []string {
+	s := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		s = append(s, string(scope))
+	}
+	return s
+}
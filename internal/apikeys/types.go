@@ -0,0 +1,58 @@
+package apikeys
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+type Scope string
+
+const (
+	// ScopeRead allows reading rates and records.
+	ScopeRead Scope = "rates:read"
+	// ScopeRefresh allows requesting and retrying refreshes.
+	ScopeRefresh Scope = "rates:refresh"
+	// ScopeAlerts allows managing alert rules.
+	ScopeAlerts Scope = "alerts"
+	// ScopeAdmin allows everything, including the admin endpoints.
+	ScopeAdmin Scope = "admin"
+)
+
+var validScopes = map[Scope]bool{
+	ScopeRead:    true,
+	ScopeRefresh: true,
+	ScopeAlerts:  true,
+	ScopeAdmin:   true,
+}
+
+// Key identifies a client. Only the hash of the secret key is stored.
+type Key struct {
+	Id         int
+	Client     string
+	Prefix     string
+	Hash       string
+	Scopes     []Scope
+	Created_At time.Time
+	// Expires_At is set for keys that were rotated or issued with an expiry.
+	Expires_At *time.Time
+	Revoked_At *time.Time
+}
+
+func (k *Key) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+func (k *Key) Active(now time.Time) bool {
+	if k.Revoked_At != nil {
+		return false
+	}
+	return k.Expires_At == nil || now.Before(*k.Expires_At)
+}
+
+var (
+	ErrNoKey         = errors.New("no api key was found")
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrInvalidClient = errors.New("invalid client name")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+)
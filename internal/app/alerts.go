@@ -0,0 +1,26 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+)
+
+const _alertsWebhookTimeout = 10 * time.Second
+
+func newAlertsNotifier(cfg config.Alerts, l *slog.Logger) (alerts.Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return alerts.NewLogNotifier(l), nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("alerts error: webhook notifier requires an url")
+		}
+		return alerts.NewWebhookNotifier(cfg.WebhookURL, _alertsWebhookTimeout), nil
+	default:
+		return nil, fmt.Errorf("alerts error: unknown notifier %q", cfg.Notifier)
+	}
+}
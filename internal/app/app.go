@@ -3,89 +3,329 @@ package app
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+	alertsrepo "github.com/ZakirAvrora/exchange-rate/internal/alerts/repo"
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	apikeysrepo "github.com/ZakirAvrora/exchange-rate/internal/apikeys/repo"
 	v1 "github.com/ZakirAvrora/exchange-rate/internal/controller/http/v1"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates/repo"
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/ZakirAvrora/exchange-rate/internal/metrics"
+	"github.com/ZakirAvrora/exchange-rate/internal/outbox"
+	outboxrepo "github.com/ZakirAvrora/exchange-rate/internal/outbox/repo"
 	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
 	"github.com/ZakirAvrora/exchange-rate/pkg/httpserver"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
 	"github.com/ZakirAvrora/exchange-rate/pkg/postgres"
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func Run(cfg *config.Config) {
+// _alertsQueueSize is how many updated records may wait for their alert
+// rules to be evaluated.
+const _alertsQueueSize = 100
+
+// Role is what a process runs.
+type Role int
+
+const (
+	// RoleAll runs the API and the workers, which share an in-memory queue.
+	RoleAll Role = iota
+	// RoleAPI runs the API only and leaves new records in the database.
+	RoleAPI
+	// RoleWorker runs the workers, which claim pending records from the
+	// database, along with the retry scheduler and the outbox relay.
+	RoleWorker
+)
+
+func Run(cfg *config.Config, l *slog.Logger, reload Reload) {
+	run(cfg, l, reload, RoleAll)
+}
+
+func run(cfg *config.Config, l *slog.Logger, reload Reload, role Role) {
+	fatal := func(msg string, err error) {
+		l.Error(msg, logger.Err(err))
+		os.Exit(1)
+	}
+
+	if err := exchangerates.SetCurrencies(cfg.Currencies.Base, cfg.Currencies.Secondary); err != nil {
+		fatal("currencies error", err)
+	}
+
+	// Tracing
+	shutdownTracing, err := tracing.New(context.Background(),
+		tracing.Exporter(cfg.Tracing.Exporter),
+		tracing.OTLPEndpoint(cfg.Tracing.OTLPEndpoint),
+		tracing.ServiceName(cfg.Tracing.ServiceName),
+		tracing.SampleRatio(cfg.Tracing.SampleRatio),
+	)
+	if err != nil {
+		fatal("tracing setup error", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			l.Error("tracing shutdown error", logger.Err(err))
+		}
+	}()
+
+	// Metrics
+	m := metrics.New()
 
 	// Repository
-	dbUrl := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.PostgresConfig.User,
-		cfg.PostgresConfig.Password,
-		cfg.PostgresConfig.Host,
-		cfg.PostgresConfig.Port,
-		cfg.PostgresConfig.DbName)
-
-	connPool, err := postgres.New(dbUrl, postgres.MaxPoolSize(cfg.PostgresConfig.PoolMax))
+	dbUrl := databaseURL(cfg.PostgresConfig)
+
+	connPool, err := postgres.New(dbUrl,
+		postgres.MaxPoolSize(cfg.PostgresConfig.PoolMax),
+		postgres.ConnAttempts(cfg.PostgresConfig.ConnAttempts),
+		postgres.ConnTimeout(cfg.PostgresConfig.ConnTimeout),
+		postgres.Tracing(),
+		postgres.Logger(l))
 	if err != nil {
-		log.Fatalln(err)
+		fatal("postgres connection error", err)
 	}
 	defer connPool.Close()
 
-	rep, err := repo.NewRecordsRepository(connPool)
+	m.RegisterPool(connPool)
+
+	pgRep, err := repo.NewRecordsRepository(connPool)
 	if err != nil {
-		log.Fatalln(err)
+		fatal("records repository error", err)
+	}
+
+	var rep exchangerates.RecordsRepo = pgRep
+	if cfg.RecordsCache.Enabled {
+		cachedRep, err := repo.NewCachedRecordsRepository(pgRep,
+			repo.CacheSize(cfg.RecordsCache.Size),
+			repo.CacheTTL(cfg.RecordsCache.TTL),
+			repo.CacheNegativeTTL(cfg.RecordsCache.NegativeTTL))
+		if err != nil {
+			fatal("records cache error", err)
+		}
+		m.RegisterRecordsCache(cachedRep.Stats)
+		rep = cachedRep
 	}
 
 	// Migrate
-	if err := initMigrate(dbUrl); err != nil {
-		log.Fatalln(err)
+	schemaVersion, err := initMigrate(dbUrl, cfg.Migrations.OnStart, l)
+	if err != nil {
+		fatal("migrate error", err)
+	}
+
+	// API keys
+	keysRep, err := apikeysrepo.NewAPIKeysRepository(connPool)
+	if err != nil {
+		fatal("api keys repository error", err)
 	}
 
+	// Alerts
+	alertsRep, err := alertsrepo.NewAlertsRepository(connPool)
+	if err != nil {
+		fatal("alerts repository error", err)
+	}
+
+	notifier, err := newAlertsNotifier(cfg.Alerts, l)
+	if err != nil {
+		fatal("alerts notifier error", err)
+	}
+
+	alerter := alerts.NewService(alertsRep, rep, notifier, alerts.WithLogger(l))
+	alertsDispatcher := alerts.NewDispatcher(alerter, _alertsQueueSize)
+
 	// Service
-	recorder := exchangerates.NewService(rep)
+	recorderOpts := []exchangerates.Option{
+		exchangerates.WithLogger(l),
+		exchangerates.WithUpdateListener(alertsDispatcher),
+		exchangerates.WithUpdateListener(m),
+		exchangerates.WithRetryPolicy(exchangerates.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   cfg.Retry.BaseDelay,
+			MaxDelay:    cfg.Retry.MaxDelay,
+		}),
+		exchangerates.WithIdempotencyTTL(cfg.Idempotency.TTL),
+		exchangerates.WithQueueSize(cfg.Queue.Size),
+	}
+	if role != RoleAll {
+		recorderOpts = append(recorderOpts, exchangerates.WithDurableQueue(cfg.Queue.Lease))
+	} else {
+		recorderOpts = append(recorderOpts, exchangerates.WithQueueLease(cfg.Queue.Lease))
+	}
+
+	var responseCache *v1.ResponseCache
+	if cfg.HTTPCache.Enabled {
+		responseCache = v1.NewResponseCache(cfg.HTTPCache.Size, cfg.HTTPCache.TTL)
+		recorderOpts = append(recorderOpts, exchangerates.WithUpdateListener(responseCache))
+	}
+
+	recorder := exchangerates.NewService(rep, recorderOpts...)
+
+	m.RegisterQueueDepth(recorder.QueueLen)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := []<-chan struct{}{alertsDispatcher.Start(ctx)}
 
 	// External client
-	client, err := exchangeratesapi.NewProvider()
+	providerOpts, keysDone, err := providerOptions(ctx, cfg.Provider, l)
 	if err != nil {
-		log.Fatalln(err)
+		fatal("external client error", err)
 	}
+	done = append(done, keysDone)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	consumer, done := NewConsumer(
-		ctx,
-		Backends{
-			ExternalAPIClient: client,
-			RecordsService:    recorder,
-		}, recorder.Queue())
+	client, err := exchangeratesapi.NewProvider(append(providerOpts,
+		exchangeratesapi.WithObserver(m.ObserveUpstream),
+		exchangeratesapi.WithTracing(),
+		exchangeratesapi.WithLogger(l),
+	)...)
+	if err != nil {
+		fatal("external client error", err)
+	}
+
+	// Health checks
+	hc := health.New(health.Timeout(cfg.Health.CheckTimeout))
+	hc.AddReadinessCheck("postgres", connPool.Ping)
+	hc.AddReadinessCheck("migrations", migrationsCheck(connPool, schemaVersion))
+	if cfg.Health.ProviderProbe {
+		hc.AddReadinessCheck("provider", providerCheck(client, cfg.Health.ProviderProbeInterval))
+	}
+
+	// Workers
+	var workers exchangerates.WorkerPool
+	if role != RoleAPI {
+		consumer, consumerDone := NewConsumer(
+			ctx,
+			Backends{
+				ExternalAPIClient: client,
+				RecordsService:    recorder,
+				RateValidator: exchangerates.NewGuard(rep,
+					exchangerates.MaxDeviation(cfg.Guard.MaxDeviation),
+					exchangerates.HistoryWindow(cfg.Guard.Window),
+					exchangerates.MinSamples(cfg.Guard.MinSamples),
+				),
+				Metrics: m,
+				Logger:  l,
+			}, recorder.Queue())
+
+		consumer.Start()
+		if err := consumer.Resize(cfg.Queue.Workers); err != nil {
+			fatal("workers error", err)
+		}
+		workers = consumer
+		done = append(done, consumerDone)
+
+		hc.AddLivenessCheck("workers", consumer.Alive)
+
+		// Claims pending records of other processes, and records this process
+		// stopped before processing once their lease expired
+		done = append(done, startQueuePoller(ctx, recorder, cfg.Queue.PollInterval, l))
+		done = append(done, startRetryScheduler(ctx, recorder, cfg.Retry.PollInterval, l))
 
-	consumer.Start()
+		// Outbox relay
+		outboxRep, err := outboxrepo.NewOutboxRepository(connPool)
+		if err != nil {
+			fatal("outbox repository error", err)
+		}
+
+		publisher, publisherCloser, err := newOutboxPublisher(cfg.Outbox)
+		if err != nil {
+			fatal("outbox publisher error", err)
+		}
+		defer publisherCloser.Close()
+
+		done = append(done, outbox.NewRelay(outboxRep, publisher, outbox.PollInterval(cfg.Outbox.PollInterval), outbox.Logger(l)).Start(ctx))
+	}
+
+	// Rate limiting
+	limiter, err := newRateLimiter(cfg.RateLimit, connPool)
+	if err != nil {
+		fatal("rate limiter error", err)
+	}
+
+	reloader := newReloader(cfg, reload, workers, l)
 
 	// HTTP Server
 	handler := gin.New()
-	v1.NewRouter(handler, recorder)
-	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
+	handler.Use(m.GinMiddleware())
+	handler.GET("/metrics", gin.WrapH(m.Handler()))
+	handler.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	services := v1.Services{
+		Records:  recorder,
+		Alerts:   alerter,
+		Health:   hc,
+		Workers:  workers,
+		Provider: client,
+		APIKeys:  apikeys.NewService(keysRep),
+		Limiter:  limiter,
+		Cache:    responseCache,
+		Config:   reloader,
+	}
+	settings := v1.Settings{
+		AdminToken:      cfg.Admin.Token,
+		APIKeysRequired: cfg.Admin.APIKeysRequired,
+		CacheMaxAge:     cfg.HTTPCache.MaxAge,
+	}
+	if role == RoleWorker {
+		v1.NewWorkerRouter(handler, services, settings, l)
+	} else {
+		v1.NewRouter(handler, services, settings, l)
+	}
+	httpServer := httpserver.New(handler,
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.ReadTimeout(cfg.HTTP.ReadTimeout),
+		httpserver.WriteTimeout(cfg.HTTP.WriteTimeout),
+		httpserver.ShutdownTimeout(cfg.HTTP.ShutdownTimeout))
 
-	// Waiting signal
+	// Waiting signal, SIGHUP reloads the configuration
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
 
-	select {
-	case s := <-interrupt:
-		log.Println("app run signal: " + s.String())
-	case err = <-httpServer.Notify():
-		log.Println(fmt.Errorf("app run error: %w", err))
+wait:
+	for {
+		select {
+		case <-hangup:
+			// NoReturnErr: the running configuration is kept
+			if _, _, err := reloader.Reload(ctx); err != nil {
+				l.Error("config reload error", logger.Err(err))
+			}
+		case s := <-interrupt:
+			l.Info("app run signal", slog.String("signal", s.String()))
+			break wait
+		case err = <-httpServer.Notify():
+			l.Error("app run error", logger.Err(err))
+			break wait
+		}
 	}
 
-	// Gracefull Shutdown
+	// Gracefull Shutdown, failing readiness first so load balancers drain the server
+	hc.Drain()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
 	err = httpServer.Shutdown()
 	if err != nil {
-		log.Println(fmt.Errorf("httpServer shutdown error: %w", err))
+		l.Error("httpServer shutdown error", logger.Err(err))
 	}
 
-	// Gracefull consumer stop
+	// Gracefull consumer, queue poller, retry scheduler, relay, alerts dispatcher and key file watcher stop
 	cancel()
-	<-done
+	for _, ch := range done {
+		<-ch
+	}
+}
+
+func databaseURL(cfg config.PostgresConfig) string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+		cfg.DbName)
 }
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates/repo"
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/postgres"
+)
+
+// Backfill runs the backfill command, which fetches the rates of the days
+// missing from the records from the provider. Progress is written to out.
+func Backfill(cfg *config.Config, l *slog.Logger, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: backfill -pairs EUR/USD,EUR/MXN -from 2024-01-01 [-to 2024-03-31] [flags]")
+		fs.PrintDefaults()
+	}
+	pairs := fs.String("pairs", "", "comma separated pairs such as EUR/USD")
+	from := fs.String("from", "", "first day to backfill")
+	to := fs.String("to", "", "last day to backfill, yesterday by default")
+	rate := fs.String("rate", "60/m", "most calls to the provider, per s, m or h")
+	reserve := fs.Int("quota-reserve", 100, "provider calls left to the refresh workers")
+	dryRun := fs.Bool("dry-run", false, "print the missing days without fetching them")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	parsedPairs, err := parsePairs(*pairs)
+	if err != nil {
+		return err
+	}
+
+	fromDay, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		fs.Usage()
+		return fmt.Errorf("backfill error: from must be a date")
+	}
+
+	toDay := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if *to != "" {
+		if toDay, err = time.Parse(time.DateOnly, *to); err != nil {
+			return fmt.Errorf("backfill error: to must be a date")
+		}
+	}
+
+	limit, err := ratelimit.ParseLimit(*rate, 0)
+	if err != nil {
+		return fmt.Errorf("backfill error: %w", err)
+	}
+	var interval time.Duration
+	if limit.Enabled() {
+		interval = limit.Period / time.Duration(limit.Rate)
+	}
+
+	if err := exchangerates.SetCurrencies(cfg.Currencies.Base, cfg.Currencies.Secondary); err != nil {
+		return fmt.Errorf("backfill error: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbUrl := databaseURL(cfg.PostgresConfig)
+
+	connPool, err := postgres.New(dbUrl,
+		postgres.MaxPoolSize(cfg.PostgresConfig.PoolMax),
+		postgres.ConnAttempts(cfg.PostgresConfig.ConnAttempts),
+		postgres.ConnTimeout(cfg.PostgresConfig.ConnTimeout),
+		postgres.Logger(l))
+	if err != nil {
+		return fmt.Errorf("backfill error: postgres connection error: %w", err)
+	}
+	defer connPool.Close()
+
+	// Dry runs leave the database as it is
+	if _, err := initMigrate(dbUrl, cfg.Migrations.OnStart && !*dryRun, l); err != nil {
+		return err
+	}
+
+	rep, err := repo.NewRecordsRepository(connPool)
+	if err != nil {
+		return fmt.Errorf("backfill error: records repository error: %w", err)
+	}
+
+	providerOpts, keysDone, err := providerOptions(ctx, cfg.Provider, l)
+	if err != nil {
+		return fmt.Errorf("backfill error: %w", err)
+	}
+	// Gracefull key file watcher stop
+	defer func() {
+		stop()
+		<-keysDone
+	}()
+
+	client, err := exchangeratesapi.NewProvider(append(providerOpts, exchangeratesapi.WithLogger(l))...)
+	if err != nil {
+		return fmt.Errorf("backfill error: external client error: %w", err)
+	}
+
+	b := exchangerates.NewBackfiller(rep, client,
+		exchangerates.BackfillInterval(interval),
+		exchangerates.QuotaReserve(*reserve),
+		exchangerates.BackfillLogger(l))
+
+	gaps, err := b.Gaps(ctx, parsedPairs, fromDay, toDay)
+	if err != nil {
+		return fmt.Errorf("backfill error: %w", err)
+	}
+
+	if *dryRun {
+		for _, gap := range gaps {
+			fmt.Fprintf(out, "%s/%s %s\n", gap.Base, gap.Secondary, gap.Day.Format(time.DateOnly))
+		}
+		fmt.Fprintf(out, "%d missing days\n", len(gaps))
+		return nil
+	}
+
+	filled, err := b.Fill(ctx, gaps, func(gap exchangerates.Gap, err error) {
+		if err != nil {
+			fmt.Fprintf(out, "%s/%s %s: %v\n", gap.Base, gap.Secondary, gap.Day.Format(time.DateOnly), err)
+		}
+	})
+	fmt.Fprintf(out, "%d of %d missing days filled\n", filled, len(gaps))
+	if err != nil {
+		return fmt.Errorf("backfill error: stopped, run it again to resume: %w", err)
+	}
+
+	return nil
+}
+
+func parsePairs(s string) ([]exchangerates.Pair, error) {
+	if s == "" {
+		return nil, fmt.Errorf("backfill error: no pairs to backfill")
+	}
+
+	var pairs []exchangerates.Pair
+	for _, p := range strings.Split(s, ",") {
+		base, secondary, ok := strings.Cut(strings.TrimSpace(p), "/")
+		if !ok {
+			return nil, fmt.Errorf("backfill error: pair %q is not like EUR/USD", p)
+		}
+		pairs = append(pairs, exchangerates.Pair{Base: base, Secondary: secondary})
+	}
+	return pairs, nil
+}
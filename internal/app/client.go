@@ -0,0 +1,201 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const _defaultServerURL = "http://localhost:8080"
+
+// apiClient calls the API of a running instance for the client commands.
+type apiClient struct {
+	url    string
+	apiKey string
+	http   *http.Client
+}
+
+// clientFlags adds the flags locating the instance, which default to the
+// EXCHANGE_RATE_URL and EXCHANGE_RATE_API_KEY variables.
+func clientFlags(fs *flag.FlagSet) *apiClient {
+	c := &apiClient{http: &http.Client{Timeout: 30 * time.Second}}
+
+	serverURL := os.Getenv("EXCHANGE_RATE_URL")
+	if serverURL == "" {
+		serverURL = _defaultServerURL
+	}
+	fs.StringVar(&c.url, "url", serverURL, "URL of the instance")
+	fs.StringVar(&c.apiKey, "api-key", os.Getenv("EXCHANGE_RATE_API_KEY"), "API key")
+
+	return c
+}
+
+// do sends the request and decodes a successful response into v, or returns
+// the detail of the problem.
+func (c *apiClient) do(ctx context.Context, method string, path string, query url.Values, body any, headers map[string]string, v any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := strings.TrimSuffix(c.url, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var p struct {
+			Code   string `json:"code"`
+			Detail string `json:"detail"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || p.Detail == "" {
+			return fmt.Errorf("request is failed: %s", resp.Status)
+		}
+		return fmt.Errorf("request is failed: %s: %s (%s)", resp.Status, p.Detail, p.Code)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Refresh runs the refresh command, which requests a refresh of a pair and
+// prints its identifier, or the record once it is processed with -wait.
+func Refresh(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	fs.SetOutput(out)
+	c := clientFlags(fs)
+	base := fs.String("base", "EUR", "base currency")
+	secondary := fs.String("secondary", "", "secondary currency")
+	key := fs.String("idempotency-key", "", "idempotency key of the request")
+	wait := fs.Duration("wait", 0, "how long to wait for the record to be processed")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if *secondary == "" {
+		return errors.New("refresh error: secondary is required")
+	}
+
+	ctx := context.Background()
+
+	headers := map[string]string{}
+	if *key != "" {
+		headers["Idempotency-Key"] = *key
+	}
+
+	var created struct {
+		Identifier string `json:"identifier"`
+	}
+	body := map[string]string{"base": *base, "secondary": *secondary}
+	if err := c.do(ctx, http.MethodPost, "/v1/exchangerates/refresh", nil, body, headers, &created); err != nil {
+		return fmt.Errorf("refresh error: %w", err)
+	}
+
+	if *wait <= 0 {
+		fmt.Fprintln(out, created.Identifier)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+
+	for {
+		var record map[string]any
+		if err := c.do(ctx, http.MethodGet, "/v1/exchangerates/"+created.Identifier, nil, nil, nil, &record); err != nil {
+			return fmt.Errorf("refresh error: %w", err)
+		}
+
+		if record["status"] != "created" {
+			record["identifier"] = created.Identifier
+			return printJSON(out, record)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("refresh error: %s is still pending", created.Identifier)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Get runs the get command, which prints a record by identifier or the
+// latest rate of a pair.
+func Get(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: get [flags] IDENTIFIER | get [flags] -secondary USD")
+		fs.PrintDefaults()
+	}
+	c := clientFlags(fs)
+	base := fs.String("base", "EUR", "base currency of the latest rate")
+	secondary := fs.String("secondary", "", "secondary currency of the latest rate")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var (
+		path  string
+		query url.Values
+	)
+	switch {
+	case fs.NArg() == 1 && *secondary == "":
+		path = "/v1/exchangerates/" + url.PathEscape(fs.Arg(0))
+	case fs.NArg() == 0 && *secondary != "":
+		path = "/v1/exchangerates/latest"
+		query = url.Values{"base": {*base}, "secondary": {*secondary}}
+	default:
+		fs.Usage()
+		return errors.New("get error: either an identifier or a secondary currency is required")
+	}
+
+	var v map[string]any
+	if err := c.do(context.Background(), http.MethodGet, path, query, nil, nil, &v); err != nil {
+		return fmt.Errorf("get error: %w", err)
+	}
+	return printJSON(out, v)
+}
+
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
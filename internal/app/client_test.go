@@ -0,0 +1,36 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("X-API-Key"))
+
+		switch {
+		case r.URL.Path == "/v1/exchangerates/latest":
+			require.Equal(t, "EUR", r.URL.Query().Get("base"))
+			require.Equal(t, "USD", r.URL.Query().Get("secondary"))
+			_, _ = w.Write([]byte(`{"rate":1.1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"record_not_found","detail":"no record"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	require.NoError(t, Get([]string{"-url", srv.URL, "-api-key", "key", "-secondary", "USD"}, &out))
+	require.JSONEq(t, `{"rate":1.1}`, out.String())
+
+	err := Get([]string{"-url", srv.URL, "-api-key", "key", "unknown"}, &out)
+	require.ErrorContains(t, err, "no record (record_not_found)")
+
+	require.Error(t, Get([]string{"-url", srv.URL}, &out))
+}
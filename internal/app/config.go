@@ -0,0 +1,21 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+)
+
+// Config runs the config command. config print writes the effective
+// configuration as YAML, with secrets redacted, so it can be used as the
+// configuration file.
+func Config(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(out, "usage: config print")
+		return errors.New("config error: unknown subcommand")
+	}
+
+	return cfg.Print(out)
+}
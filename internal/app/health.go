@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationsCheck fails while the database schema is not at the version of
+// the newest migration shipped with the binary.
+func migrationsCheck(connPool *pgxpool.Pool, want uint) health.Check {
+	return func(ctx context.Context) error {
+		var (
+			version uint
+			dirty   bool
+		)
+
+		err := connPool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("no migration applied, want version %d", want)
+		}
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+
+		if version != want {
+			return fmt.Errorf("schema version is %d, want %d", version, want)
+		}
+		return nil
+	}
+}
+
+// providerCheck probes the provider API at most once per interval, since every
+// call counts against the API quota.
+func providerCheck(client exchangeratesapi.Client, interval time.Duration) health.Check {
+	return health.Cached(func(ctx context.Context) error {
+		_, err := client.GetSupportedCurrencies(ctx)
+		return err
+	}, interval)
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates/repo"
+	"github.com/ZakirAvrora/exchange-rate/pkg/postgres"
+)
+
+// Import runs the import command, which stores the rates of CSV files as
+// updated records. The report of every file is written to out.
+func Import(cfg *config.Config, l *slog.Logger, args []string, out io.Writer) error {
+	mapping := exchangerates.DefaultImportMapping()
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: import [flags] file.csv...")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&mapping.Base, "base-column", mapping.Base, "column of the base currency")
+	fs.StringVar(&mapping.Secondary, "secondary-column", mapping.Secondary, "column of the secondary currency")
+	fs.StringVar(&mapping.Pair, "pair-column", "", "column of pairs such as EUR/USD, instead of base and secondary")
+	fs.StringVar(&mapping.Rate, "rate-column", mapping.Rate, "column of the rate")
+	fs.StringVar(&mapping.Time, "time-column", mapping.Time, "column of the time of the rate")
+	fs.StringVar(&mapping.TimeLayout, "time-layout", "", "Go layout of the time column, RFC 3339 times and dates by default")
+	delimiter := fs.String("delimiter", ",", "field delimiter")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("import error: no file to import")
+	}
+
+	comma, size := utf8.DecodeRuneInString(*delimiter)
+	if size == 0 || size != len(*delimiter) {
+		return fmt.Errorf("import error: delimiter must be a single character")
+	}
+	mapping.Comma = comma
+
+	if err := exchangerates.SetCurrencies(cfg.Currencies.Base, cfg.Currencies.Secondary); err != nil {
+		return fmt.Errorf("import error: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dbUrl := databaseURL(cfg.PostgresConfig)
+
+	connPool, err := postgres.New(dbUrl,
+		postgres.MaxPoolSize(cfg.PostgresConfig.PoolMax),
+		postgres.ConnAttempts(cfg.PostgresConfig.ConnAttempts),
+		postgres.ConnTimeout(cfg.PostgresConfig.ConnTimeout),
+		postgres.Logger(l))
+	if err != nil {
+		return fmt.Errorf("import error: postgres connection error: %w", err)
+	}
+	defer connPool.Close()
+
+	if _, err := initMigrate(dbUrl, cfg.Migrations.OnStart, l); err != nil {
+		return err
+	}
+
+	rep, err := repo.NewRecordsRepository(connPool)
+	if err != nil {
+		return fmt.Errorf("import error: records repository error: %w", err)
+	}
+
+	recorder := exchangerates.NewService(rep, exchangerates.WithLogger(l))
+
+	for _, path := range fs.Args() {
+		if err := importFile(ctx, recorder, path, mapping, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importFile(ctx context.Context, s exchangerates.RecodsService, path string, mapping exchangerates.ImportMapping, out io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("import error: %w", err)
+	}
+	defer f.Close()
+
+	report, err := s.Import(ctx, f, mapping)
+	if err != nil {
+		return fmt.Errorf("import error: %s: %w", path, err)
+	}
+
+	for _, e := range report.Errors {
+		fmt.Fprintf(out, "%s:%d: %v\n", path, e.Line, e.Err)
+	}
+	fmt.Fprintf(out, "%s: %d rows, %d imported, %d duplicates, %d errors\n",
+		path, report.Rows, report.Imported, report.Duplicates, len(report.Errors)-report.Duplicates)
+
+	return nil
+}
@@ -2,32 +2,228 @@ package app
 
 import (
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	// migrate tools
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func initMigrate(dbURL string) error {
+var errSchemaNewer = errors.New("database schema is newer than the binary")
+
+// newMigrate migrates the database from the migrations embedded in the binary.
+func newMigrate(dbURL string) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate error: opening source error: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("migrate error: postgres connect error: %w", err)
+	}
+	return m, nil
+}
+
+// initMigrate applies pending migrations when up is true and checks that the
+// binary knows the schema. It returns the version of the newest migration.
+func initMigrate(dbURL string, up bool, l *slog.Logger) (uint, error) {
+	latest, err := latestMigration()
+	if err != nil {
+		return 0, err
+	}
+
+	m, err := newMigrate(dbURL)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	if up {
+		err = m.Up()
+		if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return 0, fmt.Errorf("migrate error: up error: %w", err)
+		}
+
+		if errors.Is(err, migrate.ErrNoChange) {
+			// NoReturnErr: migration already implemented
+			l.Info("migrate: no change")
+		}
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		// NoReturnErr: the readiness check fails until migrations are applied
+		l.Warn("migrate: no migration applied", slog.Uint64("latest", uint64(latest)))
+		return latest, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("migrate error: reading version error: %w", err)
+	}
+
+	if err := checkSchema(version, latest); err != nil {
+		return 0, err
+	}
+
+	l.Info("migrate: schema version", slog.Uint64("version", uint64(version)),
+		slog.Uint64("latest", uint64(latest)), slog.Bool("dirty", dirty))
+
+	return latest, nil
+}
+
+// checkSchema refuses a schema migrated by a newer binary, whose queries may
+// not work with it. Older schemas only fail the readiness check.
+func checkSchema(version uint, latest uint) error {
+	if version > latest {
+		return fmt.Errorf("migrate error: %w: version %d, the binary knows up to %d", errSchemaNewer, version, latest)
+	}
+	return nil
+}
+
+// latestMigration returns the version of the newest embedded migration.
+func latestMigration() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("migrate error: opening source error: %w", err)
+	}
+	defer src.Close()
+
+	return lastVersion(src)
+}
 
-	m, err := migrate.New("file://migrations", dbURL)
+func lastVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
 	if err != nil {
-		return fmt.Errorf("migrate error: postgres connect error: %w", err)
+		return 0, fmt.Errorf("migrate error: reading first migration error: %w", err)
 	}
 
-	err = m.Up()
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("migrate error: reading migrations error: %w", err)
+		}
+		version = next
+	}
+}
+
+// Migrate runs the migrate command: up, down, status, version or force.
+func Migrate(cfg *config.Config, l *slog.Logger, args []string, out io.Writer) error {
+	usage := func() error {
+		fmt.Fprintln(out, "usage: migrate up [-steps n] | down [-steps n] [-all] | status | version | force VERSION")
+		return errors.New("migrate error: unknown subcommand")
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	fs.SetOutput(out)
+	steps := fs.Int("steps", 0, "number of migrations to apply or revert, every one by default for up and one for down")
+	all := fs.Bool("all", false, "revert every migration")
+
+	if err := fs.Parse(args[1:]); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	m, err := newMigrate(databaseURL(cfg.PostgresConfig))
+	if err != nil {
+		return err
+	}
 	defer m.Close()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("migrate error: up error: %w", err)
+
+	switch args[0] {
+	case "up":
+		if *steps > 0 {
+			err = m.Steps(*steps)
+		} else {
+			err = m.Up()
+		}
+	case "down":
+		switch {
+		case *all:
+			err = m.Down()
+		case *steps > 0:
+			err = m.Steps(-*steps)
+		default:
+			err = m.Steps(-1)
+		}
+	case "force":
+		if fs.NArg() != 1 {
+			return usage()
+		}
+		version, convErr := strconv.Atoi(fs.Arg(0))
+		if convErr != nil {
+			return fmt.Errorf("migrate error: invalid version %q", fs.Arg(0))
+		}
+		err = m.Force(version)
+	case "version":
+		version, dirty, err := m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Fprintln(out, 0)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("migrate error: reading version error: %w", err)
+		}
+		if dirty {
+			fmt.Fprintf(out, "%d (dirty)\n", version)
+			return nil
+		}
+		fmt.Fprintln(out, version)
+		return nil
+	case "status":
+	default:
+		return usage()
 	}
 
 	if errors.Is(err, migrate.ErrNoChange) {
-		// NoReturnErr: migration already implemented
-		log.Println("Migrate: no change")
+		// NoReturnErr: the schema is already at the requested version
+		l.Info("migrate: no change")
+	} else if err != nil {
+		return fmt.Errorf("migrate error: %s error: %w", args[0], err)
+	}
+
+	return migrationStatus(m, out)
+}
+
+func migrationStatus(m *migrate.Migrate, out io.Writer) error {
+	latest, err := latestMigration()
+	if err != nil {
+		return err
 	}
 
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintf(out, "no migration applied, latest is %d\n", latest)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migrate error: reading version error: %w", err)
+	}
+
+	status := "up to date"
+	if dirty {
+		status = "dirty, fix the schema and force the version"
+	} else if checkSchema(version, latest) != nil {
+		status = "newer than the binary, upgrade it"
+	} else if version < latest {
+		status = "migrations pending"
+	}
+	fmt.Fprintf(out, "version %d of %d, %s\n", version, latest, status)
 	return nil
 }
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	ups, err := fs.Glob(migrations.FS, "*.up.sql")
+	require.NoError(t, err)
+	downs, err := fs.Glob(migrations.FS, "*.down.sql")
+	require.NoError(t, err)
+	require.Len(t, downs, len(ups), "every migration can be reverted")
+
+	latest, err := latestMigration()
+	require.NoError(t, err)
+	require.EqualValues(t, len(ups), latest)
+}
+
+func TestCheckSchema(t *testing.T) {
+	require.NoError(t, checkSchema(11, 12))
+	require.NoError(t, checkSchema(12, 12))
+	require.ErrorIs(t, checkSchema(13, 12), errSchemaNewer)
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/outbox"
+)
+
+const _outboxHTTPTimeout = 10 * time.Second
+
+func newOutboxPublisher(cfg config.Outbox) (outbox.Publisher, io.Closer, error) {
+	switch cfg.Publisher {
+	case "stdout":
+		return outbox.NewStdoutPublisher(), io.NopCloser(nil), nil
+	case "file":
+		return outbox.NewFilePublisher(cfg.FilePath)
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, nil, fmt.Errorf("outbox error: http publisher requires an url")
+		}
+		return outbox.NewHTTPPublisher(cfg.HTTPURL, _outboxHTTPTimeout), io.NopCloser(nil), nil
+	default:
+		return nil, nil, fmt.Errorf("outbox error: unknown publisher %q", cfg.Publisher)
+	}
+}
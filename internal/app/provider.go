@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/secretfile"
+)
+
+// providerOptions point the provider client to the configured API. Keys of
+// a file are read again whenever it changes, until ctx is done. The returned
+// channel is closed once the file is no longer watched.
+func providerOptions(ctx context.Context, cfg config.Provider, l *slog.Logger) ([]exchangeratesapi.Option, <-chan struct{}, error) {
+	opts := []exchangeratesapi.Option{
+		exchangeratesapi.WithBaseURL(cfg.URL),
+		exchangeratesapi.WithKeyCooldown(cfg.KeyCooldown),
+		exchangeratesapi.WithTimeout(cfg.Timeout),
+	}
+
+	if cfg.APIKeysFile == "" {
+		done := make(chan struct{})
+		close(done)
+		return append(opts, exchangeratesapi.WithAPIKeys(exchangeratesapi.StaticKeys(cfg.APIKeys...))), done, nil
+	}
+
+	file, err := secretfile.Open(cfg.APIKeysFile, secretfile.Interval(cfg.APIKeysPollInterval), secretfile.Logger(l))
+	if err != nil {
+		return nil, nil, fmt.Errorf("provider keys error: %w", err)
+	}
+
+	return append(opts, exchangeratesapi.WithAPIKeys(file.Values)), file.Watch(ctx), nil
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+// startQueuePoller periodically claims pending records from the database for
+// the workers of this process, at once again while the queue is kept busy.
+// The returned channel is closed once it has stopped.
+func startQueuePoller(ctx context.Context, s exchangerates.RecodsService, interval time.Duration, l *slog.Logger) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				n, err := s.DispatchPending(ctx)
+				if err != nil && ctx.Err() == nil {
+					// NoReturnErr: pending records are claimed on the next poll
+					l.ErrorContext(ctx, "queue poller error", logger.Err(err))
+				}
+
+				next := interval
+				if n > 0 {
+					l.DebugContext(ctx, "queue poller claimed records", slog.Int("count", n))
+					next = 0
+				}
+				timer.Reset(next)
+			}
+		}
+	}()
+
+	return done
+}
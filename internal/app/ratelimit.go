@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	v1 "github.com/ZakirAvrora/exchange-rate/internal/controller/http/v1"
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+	ratelimitrepo "github.com/ZakirAvrora/exchange-rate/internal/ratelimit/repo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newRateLimiter(cfg config.RateLimit, connPool *pgxpool.Pool) (*ratelimit.Limiter, error) {
+	read, err := ratelimit.ParseLimit(cfg.Read, cfg.ReadBurst)
+	if err != nil {
+		return nil, fmt.Errorf("rate limit error: read limit: %w", err)
+	}
+
+	refresh, err := ratelimit.ParseLimit(cfg.Refresh, cfg.RefreshBurst)
+	if err != nil {
+		return nil, fmt.Errorf("rate limit error: refresh limit: %w", err)
+	}
+
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store, err = ratelimitrepo.NewRateLimitStore(connPool)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit error: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("rate limit error: unknown store %q", cfg.Store)
+	}
+
+	return ratelimit.NewLimiter(store, map[string]ratelimit.Limit{
+		v1.RateLimitRead:    read,
+		v1.RateLimitRefresh: refresh,
+	}), nil
+}
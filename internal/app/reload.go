@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+// Reload is what a running process needs to reload its configuration.
+type Reload struct {
+	// Load loads the configuration again from the file, the environment and
+	// the flags the process was started with.
+	Load func() (*config.Config, error)
+	// Level is the level of the logger, changed when log.level changes.
+	Level *slog.LevelVar
+}
+
+// withFlags applies the flags of a command to reloaded configurations too,
+// so that they keep overriding the file and the environment.
+func (r Reload) withFlags(args []string, flags func(*config.Config, io.Writer) *flag.FlagSet) Reload {
+	load := r.Load
+	if load == nil {
+		return r
+	}
+	r.Load = func() (*config.Config, error) {
+		cfg, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if err := flags(cfg, io.Discard).Parse(args); err != nil {
+			return nil, fmt.Errorf("config error: %w", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	return r
+}
+
+// reloader applies the changes of a reloaded configuration that are safe to
+// apply live: the worker count, the supported currencies and the log level.
+type reloader struct {
+	reload  Reload
+	workers exchangerates.WorkerPool
+	l       *slog.Logger
+
+	mu      sync.Mutex
+	current config.Config
+}
+
+// newReloader returns a reloader of cfg. workers is nil in processes
+// without workers.
+func newReloader(cfg *config.Config, reload Reload, workers exchangerates.WorkerPool, l *slog.Logger) *reloader {
+	return &reloader{
+		reload:  reload,
+		workers: workers,
+		l:       l,
+		current: *cfg,
+	}
+}
+
+// Reload loads the configuration, applies the safe changes and returns the
+// paths of the applied fields and of the fields that require a restart. An
+// invalid configuration is not applied at all.
+//
+// Fields requiring a restart keep their running value, so they are reported
+// by every reload until the process restarts. The worker count is compared
+// with the running workers, so a reload resizes workers resized by hand.
+func (r *reloader) Reload(ctx context.Context) (applied []string, restart []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.reload.Load == nil {
+		return nil, nil, fmt.Errorf("reloading configuration is failed: %w: no configuration source", config.ErrInvalid)
+	}
+
+	next, err := r.reload.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reloading configuration is failed: %w", err)
+	}
+
+	// The worker count may have been changed since, e.g. by the admin endpoint
+	if r.workers != nil {
+		r.current.Queue.Workers = r.workers.Size()
+	}
+
+	for _, path := range r.current.Changes(next) {
+		switch path {
+		case "queue.workers":
+			// Processes without workers have nothing to resize
+			if r.workers != nil {
+				if err := r.workers.Resize(next.Queue.Workers); err != nil {
+					return applied, restart, fmt.Errorf("resizing workers is failed: %w", err)
+				}
+			}
+			r.current.Queue.Workers = next.Queue.Workers
+		case "currencies.base", "currencies.secondary":
+			if err := exchangerates.SetCurrencies(next.Currencies.Base, next.Currencies.Secondary); err != nil {
+				return applied, restart, fmt.Errorf("setting currencies is failed: %w", err)
+			}
+			r.current.Currencies = next.Currencies
+		case "log.level":
+			level, err := logger.ParseLevel(next.Log.Level)
+			if err != nil {
+				return applied, restart, fmt.Errorf("setting log level is failed: %w", err)
+			}
+			if r.reload.Level != nil {
+				r.reload.Level.Set(level)
+			}
+			r.current.Log.Level = next.Log.Level
+		default:
+			restart = append(restart, path)
+			continue
+		}
+		applied = append(applied, path)
+	}
+
+	r.l.InfoContext(ctx, "config reloaded", slog.Any("applied", applied))
+	if len(restart) > 0 {
+		r.l.WarnContext(ctx, "config changes require a restart", slog.Any("fields", restart))
+	}
+
+	return applied, restart, nil
+}
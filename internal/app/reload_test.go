@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	base, secondary := exchangerates.SupportedCurrencies()
+	t.Cleanup(func() { require.NoError(t, exchangerates.SetCurrencies(base, secondary)) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workers, _ := NewConsumer(ctx, Backends{}, make(chan exchangerates.Record))
+	workers.Start()
+
+	next := config.Default()
+	var loadErr error
+	level := new(slog.LevelVar)
+	r := newReloader(config.Default(), Reload{
+		Load:  func() (*config.Config, error) { return next, loadErr },
+		Level: level,
+	}, workers, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	applied, restart, err := r.Reload(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	require.Empty(t, restart)
+
+	next = config.Default()
+	next.Queue.Workers = 3
+	next.Currencies.Secondary = []string{"USD"}
+	next.Log.Level = "debug"
+	next.HTTP.Port = "9090"
+
+	applied, restart, err = r.Reload(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"log.level", "queue.workers", "currencies.secondary"}, applied)
+	require.Equal(t, []string{"http.port"}, restart)
+	require.Equal(t, 3, workers.Size())
+	require.Equal(t, slog.LevelDebug, level.Level())
+	require.ErrorIs(t, exchangerates.ValidatePair("EUR", "GBP"), exchangerates.ErrNotSupportedSecondaryCurrency)
+
+	// Changes requiring a restart are reported until the process restarts
+	applied, restart, err = r.Reload(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	require.Equal(t, []string{"http.port"}, restart)
+
+	// Workers resized since are resized to the configured count
+	require.NoError(t, workers.Resize(7))
+	applied, _, err = r.Reload(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"queue.workers"}, applied)
+	require.Equal(t, 3, workers.Size())
+
+	// Invalid configurations are not applied
+	loadErr = errors.New("invalid")
+	next = config.Default()
+	require.NoError(t, workers.Resize(7))
+	_, _, err = r.Reload(ctx)
+	require.ErrorIs(t, err, loadErr)
+	require.Equal(t, 7, workers.Size())
+}
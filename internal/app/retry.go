@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+// startRetryScheduler periodically re-queues failed records whose next
+// attempt is due. The returned channel is closed once it has stopped.
+func startRetryScheduler(ctx context.Context, s exchangerates.RecodsService, interval time.Duration, l *slog.Logger) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.RescheduleDue(ctx)
+				if err != nil && ctx.Err() == nil {
+					// NoReturnErr: due records are claimed again on the next tick
+					l.ErrorContext(ctx, "retry scheduler error", logger.Err(err))
+				}
+				if n > 0 {
+					l.DebugContext(ctx, "retry scheduler requeued records", slog.Int("count", n))
+				}
+			}
+		}
+	}()
+
+	return done
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+
+	"github.com/ZakirAvrora/exchange-rate/config"
+)
+
+// Serve runs the serve command, which serves the API only. Refresh requests
+// are left in the database for worker processes.
+func Serve(cfg *config.Config, l *slog.Logger, reload Reload, args []string, out io.Writer) error {
+	if err := serveFlags(cfg, out).Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	run(cfg, l, reload.withFlags(args, serveFlags), RoleAPI)
+	return nil
+}
+
+func serveFlags(cfg *config.Config, out io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.StringVar(&cfg.HTTP.Port, "port", cfg.HTTP.Port, "port of the API")
+	fs.BoolVar(&cfg.Migrations.OnStart, "migrate", cfg.Migrations.OnStart, "apply migrations at start")
+	return fs
+}
+
+// Worker runs the worker command, which refreshes the records pending in
+// the database. Its port serves the health checks, the metrics and the
+// pipeline admin endpoints.
+func Worker(cfg *config.Config, l *slog.Logger, reload Reload, args []string, out io.Writer) error {
+	if err := workerFlags(cfg, out).Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	run(cfg, l, reload.withFlags(args, workerFlags), RoleWorker)
+	return nil
+}
+
+func workerFlags(cfg *config.Config, out io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.StringVar(&cfg.HTTP.Port, "port", cfg.HTTP.Port, "port of the health checks and metrics")
+	fs.IntVar(&cfg.Queue.Workers, "workers", cfg.Queue.Workers, "number of workers")
+	fs.DurationVar(&cfg.Queue.PollInterval, "poll-interval", cfg.Queue.PollInterval, "how often pending records are looked for")
+	fs.DurationVar(&cfg.Queue.Lease, "lease", cfg.Queue.Lease, "how long a claimed record is left to this process")
+	fs.BoolVar(&cfg.Migrations.OnStart, "migrate", cfg.Migrations.OnStart, "apply migrations at start")
+	return fs
+}
@@ -2,18 +2,36 @@ package app
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ZakirAvrora/exchange-rate/config"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/internal/metrics"
 	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const _defaultWorkerNumber = 5
+const (
+	_defaultWorkerNumber = config.DefaultQueueWorkers
+	_maxWorkerNumber     = config.MaxQueueWorkers
+)
 
 type Backends struct {
 	RecordsService    exchangerates.RecodsService
 	ExternalAPIClient exchangeratesapi.Client
+	RateValidator     exchangerates.RateValidator
+	Metrics           *metrics.Metrics
+	Logger            *slog.Logger
 }
 
 type consumer struct {
@@ -23,12 +41,29 @@ type consumer struct {
 	workerNumber int
 	wg           *sync.WaitGroup
 	doneChan     chan struct{}
+	running      atomic.Int32
+
+	mu       sync.Mutex
+	workers  []*worker
+	nextID   int
+	inFlight map[int]exchangerates.InFlight
+	// resumed is closed while workers take records, paused while they don't.
+	resumed chan struct{}
+	paused  chan struct{}
+}
+
+type worker struct {
+	id   int
+	stop chan struct{}
 }
 
 func NewConsumer(ctx context.Context, b Backends, ch <-chan exchangerates.Record) (*consumer, <-chan struct{}) {
 	doneChan := make(chan struct{})
 	var wg sync.WaitGroup
 
+	resumed := make(chan struct{})
+	close(resumed)
+
 	return &consumer{
 		b:            b,
 		queue:        ch,
@@ -36,39 +71,253 @@ func NewConsumer(ctx context.Context, b Backends, ch <-chan exchangerates.Record
 		workerNumber: _defaultWorkerNumber,
 		wg:           &wg,
 		doneChan:     doneChan,
+		inFlight:     make(map[int]exchangerates.InFlight),
+		resumed:      resumed,
+		paused:       make(chan struct{}),
 	}, doneChan
 }
 
 func (c *consumer) Start() {
+	// NoReturnErr: the default worker number is valid
+	_ = c.Resize(c.workerNumber)
+
 	go func() {
-		for workerNumber := 0; workerNumber < c.workerNumber; workerNumber++ {
-			c.wg.Add(1)
-			go func() {
-				defer c.wg.Done()
-				ctx := c.ctx
-				for {
-					select {
-					case <-ctx.Done():
-						return
-					case record := <-c.queue:
-						rate, err := c.b.ExternalAPIClient.GetLatestRate(ctx, record.Base, record.Secondary)
-						if err != nil {
-							if err = c.b.RecordsService.ShiftFailed(ctx, record.Identifier); err != nil {
-								// NoReturnErr: log error and continue work on other tasks
-								log.Println(err, record.Identifier)
-							}
-						}
-
-						if err = c.b.RecordsService.ShiftUpdated(ctx, record.Identifier, rate.Value); err != nil {
-							// NoReturnErr: log error and continue work on other tasks
-							log.Println(err, record.Identifier)
-						}
-					}
-				}
-			}()
-		}
+		<-c.ctx.Done()
 		c.wg.Wait()
 		c.doneChan <- struct{}{}
 	}()
+}
+
+// Alive reports an error once any worker goroutine has exited.
+func (c *consumer) Alive(context.Context) error {
+	size := c.Size()
+	if running := int(c.running.Load()); running < size {
+		return fmt.Errorf("%d of %d workers are running", running, size)
+	}
+	return nil
+}
+
+func (c *consumer) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pausedLocked() {
+		return
+	}
+	c.resumed = make(chan struct{})
+	close(c.paused)
+}
+
+func (c *consumer) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.pausedLocked() {
+		return
+	}
+	c.paused = make(chan struct{})
+	close(c.resumed)
+}
+
+func (c *consumer) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.pausedLocked()
+}
+
+func (c *consumer) pausedLocked() bool {
+	select {
+	case <-c.paused:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resize starts new workers, or stops the most recently started ones once
+// they finished their in-flight record.
+func (c *consumer) Resize(n int) error {
+	if n < 1 || n > _maxWorkerNumber {
+		return fmt.Errorf("%w: must be between 1 and %d", exchangerates.ErrInvalidWorkerCount, _maxWorkerNumber)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return fmt.Errorf("resizing workers is failed: %w", c.ctx.Err())
+	}
+
+	for len(c.workers) < n {
+		c.nextID++
+		w := &worker{id: c.nextID, stop: make(chan struct{})}
+		c.workers = append(c.workers, w)
+
+		c.wg.Add(1)
+		c.running.Add(1)
+		go c.work(w)
+	}
+
+	for len(c.workers) > n {
+		last := len(c.workers) - 1
+		close(c.workers[last].stop)
+		c.workers = c.workers[:last]
+	}
+
+	return nil
+}
+
+func (c *consumer) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.workers)
+}
+
+// InFlight returns the records being processed, by worker.
+func (c *consumer) InFlight() []exchangerates.InFlight {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inFlight := make([]exchangerates.InFlight, 0, len(c.inFlight))
+	for _, f := range c.inFlight {
+		inFlight = append(inFlight, f)
+	}
+	sort.Slice(inFlight, func(i, j int) bool { return inFlight[i].Worker < inFlight[j].Worker })
+
+	return inFlight
+}
+
+func (c *consumer) work(w *worker) {
+	defer c.wg.Done()
+	defer c.running.Add(-1)
+
+	for {
+		c.mu.Lock()
+		resumed, paused := c.resumed, c.paused
+		c.mu.Unlock()
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-resumed:
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-paused:
+		case record := <-c.queue:
+			c.track(w.id, &record)
+			c.process(c.ctx, record)
+			c.track(w.id, nil)
+		}
+	}
+}
+
+func (c *consumer) track(workerID int, record *exchangerates.Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if record == nil {
+		delete(c.inFlight, workerID)
+		return
+	}
+
+	c.inFlight[workerID] = exchangerates.InFlight{
+		Worker:     workerID,
+		Identifier: record.Identifier,
+		Base:       record.Base,
+		Secondary:  record.Secondary,
+		Started_At: time.Now(),
+	}
+}
+
+func (c *consumer) process(ctx context.Context, record exchangerates.Record) {
+	// The record crossed an async boundary, so the processing span starts a
+	// new trace linked to the request that queued the record.
+	spanOpts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("record.identifier", record.Identifier),
+			attribute.String("record.base", record.Base),
+			attribute.String("record.secondary", record.Secondary),
+		),
+	}
+	if link, ok := tracing.LinkFromTraceParent(record.TraceParent); ok {
+		spanOpts = append(spanOpts, trace.WithLinks(link))
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "worker.process", spanOpts...)
+	defer span.End()
+
+	ctx = logger.WithRequestID(ctx, record.RequestID)
+	l := c.b.Logger.With(slog.String("identifier", record.Identifier))
+
+	c.b.Metrics.WorkerStarted()
+	result := exchangerates.StatusFailed
+	defer func() {
+		span.SetAttributes(attribute.String("record.result", result.String()))
+		c.b.Metrics.WorkerFinished(result.String())
+	}()
+
+	// Records cancelled while queued are skipped, sparing the provider call
+	current, err := c.b.RecordsService.FetchByIdentifier(ctx, record.Identifier)
+	if err == nil && current.Status != exchangerates.StatusCreated {
+		result = current.Status
+		l.InfoContext(ctx, "skipping record that is no longer pending", slog.String("status", current.Status.String()))
+		return
+	}
+
+	rate, err := c.b.ExternalAPIClient.GetLatestRate(ctx, record.Base, record.Secondary)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		l.WarnContext(ctx, "fetching latest rate is failed", logger.Err(err))
+		if err = c.b.RecordsService.ShiftFailed(ctx, record.Identifier, err); err != nil {
+			// NoReturnErr: log error and continue work on other tasks
+			logShiftError(ctx, l, "shifting record to failed error", err)
+		}
+		return
+	}
+
+	err = c.b.RateValidator.Validate(ctx, record.Base, record.Secondary, rate.Value)
+	if errors.Is(err, exchangerates.ErrImplausibleRate) {
+		l.WarnContext(ctx, "quarantining implausible rate", logger.Err(err), slog.Float64("rate", rate.Value))
+		result = exchangerates.StatusQuarantined
+		if err = c.b.RecordsService.ShiftQuarantined(ctx, record.Identifier, rate.Value); err != nil {
+			// NoReturnErr: log error and continue work on other tasks
+			logShiftError(ctx, l, "shifting record to quarantined error", err)
+		}
+		return
+	}
+
+	if err != nil {
+		// NoReturnErr: history is unavailable, the tick is stored unvalidated
+		l.WarnContext(ctx, "validating rate error", logger.Err(err))
+	}
+
+	result = exchangerates.StatusUpdated
+	if err = c.b.RecordsService.ShiftUpdated(ctx, record.Identifier, rate.Value); err != nil {
+		// NoReturnErr: log error and continue work on other tasks
+		logShiftError(ctx, l, "shifting record to updated error", err)
+		return
+	}
+
+	l.DebugContext(ctx, "record updated", slog.Float64("rate", rate.Value))
+}
 
+// logShiftError logs records cancelled during processing as expected.
+func logShiftError(ctx context.Context, l *slog.Logger, msg string, err error) {
+	if errors.Is(err, exchangerates.ErrNotPending) {
+		l.InfoContext(ctx, "record was cancelled while processing", logger.Err(err))
+		return
+	}
+	l.ErrorContext(ctx, msg, logger.Err(err))
 }
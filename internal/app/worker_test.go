@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumer_PauseAndResize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	queue := make(chan exchangerates.Record, 1)
+
+	c, done := NewConsumer(ctx, Backends{}, queue)
+	c.Start()
+	require.Equal(t, _defaultWorkerNumber, c.Size())
+	require.NoError(t, c.Alive(ctx))
+
+	c.Pause()
+	require.True(t, c.Paused())
+
+	// Paused workers leave queued records alone
+	queue <- exchangerates.Record{Identifier: "identifier"}
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, queue, 1)
+	<-queue
+
+	require.NoError(t, c.Resize(2))
+	require.Equal(t, 2, c.Size())
+	require.NoError(t, c.Resize(8))
+	require.Equal(t, 8, c.Size())
+	require.ErrorIs(t, c.Resize(0), exchangerates.ErrInvalidWorkerCount)
+
+	c.Resume()
+	require.False(t, c.Paused())
+	require.Empty(t, c.InFlight())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers did not stop")
+	}
+}
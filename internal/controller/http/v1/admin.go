@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/gin-gonic/gin"
+)
+
+type adminRoutes struct {
+	t exchangerates.RecodsService
+	w exchangerates.WorkerPool
+	p exchangeratesapi.Client
+}
+
+func newAdminRoutes(handler *gin.RouterGroup, s Services, token string) {
+	r := &adminRoutes{s.Records, s.Workers, s.Provider}
+	k := &adminKeysRoutes{s.APIKeys}
+
+	h := handler.Group("/admin", adminAuth(token))
+	{
+		h.GET("/quarantine", r.quarantined)
+		h.POST("/quarantine/:id/accept", r.accept)
+		h.POST("/quarantine/:id/reject", r.reject)
+
+		// Processes without workers have no pipeline
+		if r.w != nil {
+			newPipelineRoutes(h, r)
+		}
+
+		h.GET("/records/stats", r.stats)
+		h.POST("/records/:id/cancel", r.cancel)
+		h.POST("/records/import", r.importRates)
+		h.GET("/failures", r.failures)
+		h.GET("/provider/quota", r.quota)
+
+		if s.Config != nil {
+			newConfigRoutes(h, s.Config)
+		}
+
+		h.POST("/apikeys", k.issue)
+		h.GET("/apikeys", k.list)
+		h.POST("/apikeys/:id/rotate", k.rotate)
+		h.DELETE("/apikeys/:id", k.revoke)
+	}
+}
+
+func newPipelineRoutes(h *gin.RouterGroup, r *adminRoutes) {
+	h.GET("/pipeline", r.pipeline)
+	h.POST("/pipeline/pause", r.pause)
+	h.POST("/pipeline/resume", r.resume)
+	h.PUT("/pipeline/workers", r.resize)
+}
+
+// adminAuth requires an admin scoped API key, or the admin token as a bearer
+// token. The token is disabled when it is not configured.
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := apikeys.ClientFrom(c.Request.Context()); ok {
+			if !key.HasScope(apikeys.ScopeAdmin) {
+				processError(c, fmt.Errorf("%w: %s", errMissingScope, apikeys.ScopeAdmin))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if token == "" {
+			processError(c, errAdminDisabled)
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			processError(c, errInvalidAdminToken)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type quarantinedResponse struct {
+	Identifier string    `json:"identifier"`
+	Base       string    `json:"base"`
+	Secondary  string    `json:"secondary"`
+	Rate       float64   `json:"rate"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// @Summary     List quarantined records
+// @Description Records whose provider rate deviated implausibly from recent history wait here for a decision.
+// @ID          list-quarantined
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {array} quarantinedResponse
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/quarantine [get]
+func (r *adminRoutes) quarantined(c *gin.Context) {
+	records, err := r.t.FetchQuarantined(c.Request.Context())
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := make([]quarantinedResponse, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, quarantinedResponse{
+			Identifier: record.Identifier,
+			Base:       record.Base,
+			Secondary:  record.Secondary,
+			Rate:       record.Rate,
+			UpdateTime: record.Updated_At,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Accept quarantined rate
+// @Description The quarantined rate becomes the updated rate of the record.
+// @ID          accept-quarantined
+// @Tags  	    admin
+// @Security    AdminToken
+// @Param       id path string true "unique identifier" Format(uuid)
+// @Success     204
+// @Failure     401 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/quarantine/{id}/accept [post]
+func (r *adminRoutes) accept(c *gin.Context) {
+	if err := r.t.AcceptQuarantined(c.Request.Context(), c.Param("id")); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Reject quarantined rate
+// @Description The quarantined rate is discarded and the record is failed.
+// @ID          reject-quarantined
+// @Tags  	    admin
+// @Security    AdminToken
+// @Param       id path string true "unique identifier" Format(uuid)
+// @Success     204
+// @Failure     401 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/quarantine/{id}/reject [post]
+func (r *adminRoutes) reject(c *gin.Context) {
+	if err := r.t.RejectQuarantined(c.Request.Context(), c.Param("id")); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
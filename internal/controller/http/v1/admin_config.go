@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type configRoutes struct {
+	c ConfigReloader
+}
+
+type reloadResponse struct {
+	Applied         []string `json:"applied"          example:"queue.workers,log.level"`
+	RestartRequired []string `json:"restart_required" example:"http.port"`
+}
+
+func newConfigRoutes(h *gin.RouterGroup, c ConfigReloader) {
+	r := &configRoutes{c}
+
+	h.POST("/config/reload", r.reload)
+}
+
+// @Summary     Reload the configuration
+// @Description Loads the configuration file, the environment and the flags again, like SIGHUP. The worker count, the supported currencies and the log level are applied live, other changes require a restart. An invalid configuration is not applied at all.
+// @ID          reload-config
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} reloadResponse
+// @Failure     401 {object} problem
+// @Failure     422 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/config/reload [post]
+func (r *configRoutes) reload(c *gin.Context) {
+	applied, restart, err := r.c.Reload(c.Request.Context())
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := reloadResponse{
+		Applied:         append([]string{}, applied...),
+		RestartRequired: append([]string{}, restart...),
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+// _maxImportSize bounds uploaded files, larger histories are imported with
+// the import command.
+const _maxImportSize = 64 << 20
+
+type importRowError struct {
+	Line  int    `json:"line"  example:"42"`
+	Code  string `json:"code"  example:"unsupported_secondary_currency"`
+	Error string `json:"error" example:"not supported secondary currency"`
+}
+
+type importResponse struct {
+	Rows       int              `json:"rows"`
+	Imported   int              `json:"imported"`
+	Duplicates int              `json:"duplicates"`
+	Errors     []importRowError `json:"errors"`
+}
+
+// @Summary     Import historical rates
+// @Description Stores the rates of a CSV file, sent as the file field of a form or as the request body, as updated
+// @Description records with the imported source. The header names the columns, which are mapped by the query.
+// @Description Invalid rows and rows whose pair already has a rate at the same time are skipped and reported by line.
+// @ID          import-rates
+// @Tags  	    admin
+// @Accept      text/csv
+// @Accept      multipart/form-data
+// @Produce     json
+// @Security    AdminToken
+// @Param       base_column query string false "column of the base currency" default(base)
+// @Param       secondary_column query string false "column of the secondary currency" default(secondary)
+// @Param       pair_column query string false "column of pairs such as EUR/USD, instead of base and secondary"
+// @Param       rate_column query string false "column of the rate" default(rate)
+// @Param       time_column query string false "column of the time of the rate" default(time)
+// @Param       time_layout query string false "Go layout of the time column, RFC 3339 times and dates by default" example(02/01/2006)
+// @Param       delimiter query string false "field delimiter" default(,)
+// @Success     200 {object} importResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/records/import [post]
+func (r *adminRoutes) importRates(c *gin.Context) {
+	mapping, err := importMapping(c)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, _maxImportSize)
+
+	var src io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			processError(c, importFileError(fmt.Errorf("%w: %w", errInvalidRequest, err)))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			processError(c, err)
+			return
+		}
+		defer f.Close()
+		src = f
+	}
+
+	report, err := r.t.Import(c.Request.Context(), src, mapping)
+	if err != nil {
+		processError(c, importFileError(err))
+		return
+	}
+
+	resp := importResponse{
+		Rows:       report.Rows,
+		Imported:   report.Imported,
+		Duplicates: report.Duplicates,
+		Errors:     make([]importRowError, 0, len(report.Errors)),
+	}
+	for _, e := range report.Errors {
+		code, detail := batchError(e.Err)
+		resp.Errors = append(resp.Errors, importRowError{Line: e.Line, Code: code, Error: detail})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func importMapping(c *gin.Context) (exchangerates.ImportMapping, error) {
+	mapping := exchangerates.DefaultImportMapping()
+	mapping.Base = c.DefaultQuery("base_column", mapping.Base)
+	mapping.Secondary = c.DefaultQuery("secondary_column", mapping.Secondary)
+	mapping.Pair = c.Query("pair_column")
+	mapping.Rate = c.DefaultQuery("rate_column", mapping.Rate)
+	mapping.Time = c.DefaultQuery("time_column", mapping.Time)
+	mapping.TimeLayout = c.Query("time_layout")
+
+	if delimiter := c.Query("delimiter"); delimiter != "" {
+		comma, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return mapping, fmt.Errorf("%w: delimiter must be a single character", errInvalidRequest)
+		}
+		mapping.Comma = comma
+	}
+
+	return mapping, nil
+}
+
+// importFileError reports files over the size limit as invalid requests.
+func importFileError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: the file is larger than %d bytes", errInvalidRequest, maxBytesErr.Limit)
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/gin-gonic/gin"
+)
+
+const _defaultRotationGrace = 24 * time.Hour
+
+type adminKeysRoutes struct {
+	k apikeys.Service
+}
+
+type issueKeyRequest struct {
+	Client    string   `json:"client"     binding:"required"  example:"billing-service"`
+	Scopes    []string `json:"scopes"     binding:"required"  example:"rates:read,rates:refresh" enums:"rates:read,rates:refresh,alerts,admin"`
+	ExpiresIn string   `json:"expires_in" example:"720h"`
+}
+
+type rotateKeyRequest struct {
+	Grace string `json:"grace" example:"24h"`
+}
+
+type keyResponse struct {
+	Id        int        `json:"id"`
+	Client    string     `json:"client"       example:"billing-service"`
+	Prefix    string     `json:"prefix"       example:"erk_Zk3x9aQ1"`
+	Scopes    []string   `json:"scopes"       example:"rates:read"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type issuedKeyResponse struct {
+	keyResponse
+	// Key is only returned once, when it is issued.
+	Key string `json:"key" example:"erk_Zk3x9aQ1..."`
+}
+
+// @Summary     Issue an API key
+// @Description The key is returned once and only its hash is stored.
+// @ID          issue-api-key
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminToken
+// @Param       request body issueKeyRequest true "Client and scopes"
+// @Success     201 {object} issuedKeyResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/apikeys [post]
+func (r *adminKeysRoutes) issue(c *gin.Context) {
+	var request issueKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		processError(c, bindingError(err))
+		return
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresIn != "" {
+		d, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil || d <= 0 {
+			processError(c, fmt.Errorf("%w: invalid expires_in duration", errInvalidRequest))
+			return
+		}
+		at := time.Now().Add(d)
+		expiresAt = &at
+	}
+
+	scopes := make([]apikeys.Scope, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		scopes = append(scopes, apikeys.Scope(scope))
+	}
+
+	key, secret, err := r.k.Issue(c.Request.Context(), request.Client, scopes, expiresAt)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, issuedKeyResponse{newKeyResponse(key), secret})
+}
+
+// @Summary     List API keys
+// @ID          list-api-keys
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {array} keyResponse
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/apikeys [get]
+func (r *adminKeysRoutes) list(c *gin.Context) {
+	keys, err := r.k.List(c.Request.Context())
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := make([]keyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, newKeyResponse(&keys[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Rotate an API key
+// @Description Issues a key with the same client and scopes. The rotated key keeps working for the grace period.
+// @ID          rotate-api-key
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminToken
+// @Param       id path int true "api key id"
+// @Param       request body rotateKeyRequest false "Grace period of the rotated key, 24h by default"
+// @Success     201 {object} issuedKeyResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/apikeys/{id}/rotate [post]
+func (r *adminKeysRoutes) rotate(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+
+	var request rotateKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			processError(c, bindingError(err))
+			return
+		}
+	}
+
+	grace := _defaultRotationGrace
+	if request.Grace != "" {
+		var err error
+		grace, err = time.ParseDuration(request.Grace)
+		if err != nil || grace < 0 {
+			processError(c, fmt.Errorf("%w: invalid grace duration", errInvalidRequest))
+			return
+		}
+	}
+
+	key, secret, err := r.k.Rotate(c.Request.Context(), id, grace)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, issuedKeyResponse{newKeyResponse(key), secret})
+}
+
+// @Summary     Revoke an API key
+// @ID          revoke-api-key
+// @Tags  	    admin
+// @Security    AdminToken
+// @Param       id path int true "api key id"
+// @Success     204
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/apikeys/{id} [delete]
+func (r *adminKeysRoutes) revoke(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+
+	if err := r.k.Revoke(c.Request.Context(), id); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func keyID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		processError(c, fmt.Errorf("%w: invalid api key id", errInvalidRequest))
+		return 0, false
+	}
+	return id, true
+}
+
+func newKeyResponse(key *apikeys.Key) keyResponse {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	return keyResponse{
+		Id:        key.Id,
+		Client:    key.Client,
+		Prefix:    key.Prefix,
+		Scopes:    scopes,
+		CreatedAt: key.Created_At,
+		ExpiresAt: key.Expires_At,
+		RevokedAt: key.Revoked_At,
+	}
+}
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+const _defaultFailuresWindow = 24 * time.Hour
+
+type inFlightResponse struct {
+	Worker     int       `json:"worker"`
+	Identifier string    `json:"identifier"`
+	Base       string    `json:"base"`
+	Secondary  string    `json:"secondary"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+type pipelineResponse struct {
+	QueueLength int                `json:"queue_length"`
+	Paused      bool               `json:"paused"`
+	Workers     int                `json:"workers"`
+	InFlight    []inFlightResponse `json:"in_flight"`
+}
+
+type resizeRequest struct {
+	Count int `json:"count" binding:"required" example:"5"`
+}
+
+type failureCountResponse struct {
+	Code        string    `json:"code"          example:"timeout"`
+	Count       int       `json:"count"`
+	LastMessage string    `json:"last_message"`
+	LastAt      time.Time `json:"last_at"`
+}
+
+type quotaResponse struct {
+	Limit     *int       `json:"limit"`
+	Remaining *int       `json:"remaining"`
+	Calls     int64      `json:"calls"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// @Summary     Show the refresh pipeline
+// @Description Queue length, whether the workers are paused, the worker count and the record each worker processes.
+// @ID          show-pipeline
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} pipelineResponse
+// @Failure     401 {object} problem
+// @Router      /admin/pipeline [get]
+func (r *adminRoutes) pipeline(c *gin.Context) {
+	c.JSON(http.StatusOK, r.pipelineResponse())
+}
+
+// @Summary     Pause the workers
+// @Description Workers stop taking queued records, records in flight are finished.
+// @ID          pause-pipeline
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} pipelineResponse
+// @Failure     401 {object} problem
+// @Router      /admin/pipeline/pause [post]
+func (r *adminRoutes) pause(c *gin.Context) {
+	r.w.Pause()
+	c.JSON(http.StatusOK, r.pipelineResponse())
+}
+
+// @Summary     Resume the workers
+// @ID          resume-pipeline
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} pipelineResponse
+// @Failure     401 {object} problem
+// @Router      /admin/pipeline/resume [post]
+func (r *adminRoutes) resume(c *gin.Context) {
+	r.w.Resume()
+	c.JSON(http.StatusOK, r.pipelineResponse())
+}
+
+// @Summary     Change the worker count
+// @Description Stopped workers finish their record in flight first.
+// @ID          resize-pipeline
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminToken
+// @Param       request body resizeRequest true "Worker count"
+// @Success     200 {object} pipelineResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Router      /admin/pipeline/workers [put]
+func (r *adminRoutes) resize(c *gin.Context) {
+	var request resizeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		processError(c, bindingError(err))
+		return
+	}
+
+	if err := r.w.Resize(request.Count); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, r.pipelineResponse())
+}
+
+func (r *adminRoutes) pipelineResponse() pipelineResponse {
+	inFlight := r.w.InFlight()
+
+	resp := pipelineResponse{
+		QueueLength: r.t.QueueLen(),
+		Paused:      r.w.Paused(),
+		Workers:     r.w.Size(),
+		InFlight:    make([]inFlightResponse, 0, len(inFlight)),
+	}
+	for _, f := range inFlight {
+		resp.InFlight = append(resp.InFlight, inFlightResponse{
+			Worker:     f.Worker,
+			Identifier: f.Identifier,
+			Base:       f.Base,
+			Secondary:  f.Secondary,
+			StartedAt:  f.Started_At,
+		})
+	}
+
+	return resp
+}
+
+// @Summary     Count records by status
+// @ID          record-stats
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} map[string]int
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/records/stats [get]
+func (r *adminRoutes) stats(c *gin.Context) {
+	counts, err := r.t.CountByStatus(c.Request.Context())
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := make(map[string]int)
+	for status := exchangerates.StatusCreated; status < exchangerates.StatusSentinel; status++ {
+		resp[status.String()] = counts[status]
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Cancel a pending record
+// @Description Created records, and failed records waiting to be retried, are not processed anymore.
+// @ID          cancel-record
+// @Tags  	    admin
+// @Security    AdminToken
+// @Param       id path string true "unique identifier" Format(uuid)
+// @Success     204
+// @Failure     401 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/records/{id}/cancel [post]
+func (r *adminRoutes) cancel(c *gin.Context) {
+	if err := r.t.Cancel(c.Request.Context(), c.Param("id")); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Recent failures by reason
+// @Description Failed and exhausted records updated within the window, grouped by failure code.
+// @ID          failures-by-reason
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Param       since query string false "window, e.g. 1h" default(24h)
+// @Success     200 {array} failureCountResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     500 {object} problem
+// @Router      /admin/failures [get]
+func (r *adminRoutes) failures(c *gin.Context) {
+	window := _defaultFailuresWindow
+	if since := c.Query("since"); since != "" {
+		var err error
+		window, err = time.ParseDuration(since)
+		if err != nil || window <= 0 {
+			processError(c, fmt.Errorf("%w: invalid since duration", errInvalidRequest))
+			return
+		}
+	}
+
+	counts, err := r.t.FailureCounts(c.Request.Context(), time.Now().Add(-window))
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := make([]failureCountResponse, 0, len(counts))
+	for _, fc := range counts {
+		resp = append(resp, failureCountResponse{
+			Code:        string(fc.Code),
+			Count:       fc.Count,
+			LastMessage: fc.LastMessage,
+			LastAt:      fc.Last_At,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Provider API quota
+// @Description Limit and remaining calls as reported by the provider in its latest response, and the calls made since start.
+// @ID          provider-quota
+// @Tags  	    admin
+// @Produce     json
+// @Security    AdminToken
+// @Success     200 {object} quotaResponse
+// @Failure     401 {object} problem
+// @Router      /admin/provider/quota [get]
+func (r *adminRoutes) quota(c *gin.Context) {
+	quota := r.p.Quota()
+
+	resp := quotaResponse{
+		Limit:     quota.Limit,
+		Remaining: quota.Remaining,
+		Calls:     quota.Calls,
+	}
+	if !quota.Updated_At.IsZero() {
+		resp.UpdatedAt = &quota.Updated_At
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
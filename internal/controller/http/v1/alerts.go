@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/gin-gonic/gin"
+)
+
+type alertsRoutes struct {
+	a alerts.Service
+}
+
+func newAlertsRoutes(handler *gin.RouterGroup, a alerts.Service, au auth) {
+	r := &alertsRoutes{a}
+
+	h := handler.Group("/alerts", au.require(apikeys.ScopeAlerts))
+	{
+		h.POST("", r.create)
+		h.GET("", r.list)
+		h.GET("/:id", r.fetch)
+		h.PUT("/:id", r.update)
+		h.DELETE("/:id", r.delete)
+	}
+}
+
+type alertRuleRequest struct {
+	Base      string  `json:"base"       binding:"required"  example:"EUR"`
+	Secondary string  `json:"secondary"  binding:"required"  example:"USD"`
+	Kind      string  `json:"kind"       binding:"required"  example:"change" enums:"change,cross"`
+	Threshold float64 `json:"threshold"  binding:"required"  example:"0.5"`
+	Window    string  `json:"window"     example:"1h"`
+	Cooldown  string  `json:"cooldown"   example:"30m"`
+}
+
+type alertRuleResponse struct {
+	Id              int        `json:"id"`
+	Base            string     `json:"base"`
+	Secondary       string     `json:"secondary"`
+	Kind            string     `json:"kind"`
+	Threshold       float64    `json:"threshold"`
+	Window          string     `json:"window,omitempty"`
+	Cooldown        string     `json:"cooldown"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (req alertRuleRequest) toRule() (*alerts.Rule, error) {
+	rule := &alerts.Rule{
+		Base:      req.Base,
+		Secondary: req.Secondary,
+		Kind:      alerts.RuleKind(req.Kind),
+		Threshold: req.Threshold,
+	}
+
+	var err error
+	if req.Window != "" {
+		if rule.Window, err = time.ParseDuration(req.Window); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Cooldown != "" {
+		if rule.Cooldown, err = time.ParseDuration(req.Cooldown); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+func newAlertRuleResponse(rule *alerts.Rule) alertRuleResponse {
+	resp := alertRuleResponse{
+		Id:              rule.Id,
+		Base:            rule.Base,
+		Secondary:       rule.Secondary,
+		Kind:            string(rule.Kind),
+		Threshold:       rule.Threshold,
+		Cooldown:        rule.Cooldown.String(),
+		LastTriggeredAt: rule.LastTriggered_At,
+		CreatedAt:       rule.Created_At,
+		UpdatedAt:       rule.Updated_At,
+	}
+
+	if rule.Window > 0 {
+		resp.Window = rule.Window.String()
+	}
+
+	return resp
+}
+
+// @Summary     Create alert rule
+// @Description A "change" rule triggers when the rate moves more than threshold percent within the window.
+// @Description A "cross" rule triggers when the rate crosses the threshold level.
+// @Description A triggered rule is silent for the cooldown duration.
+// @ID          create-alert-rule
+// @Tags  	    alerts
+// @Accept      json
+// @Produce     json
+// @Security    ApiKey
+// @Param       request body alertRuleRequest true "Set up alert rule"
+// @Success     201 {object} alertRuleResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     500 {object} problem
+// @Router      /alerts [post]
+func (r *alertsRoutes) create(c *gin.Context) {
+	var request alertRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		processError(c, bindingError(err))
+		return
+	}
+
+	rule, err := request.toRule()
+	if err != nil {
+		processError(c, fmt.Errorf("%w: invalid duration", errInvalidRequest))
+		return
+	}
+
+	if err := r.a.Create(c.Request.Context(), rule); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAlertRuleResponse(rule))
+}
+
+// @Summary     List alert rules
+// @ID          list-alert-rules
+// @Tags  	    alerts
+// @Produce     json
+// @Security    ApiKey
+// @Success     200 {array} alertRuleResponse
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     500 {object} problem
+// @Router      /alerts [get]
+func (r *alertsRoutes) list(c *gin.Context) {
+	rules, err := r.a.List(c.Request.Context())
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := make([]alertRuleResponse, 0, len(rules))
+	for i := range rules {
+		resp = append(resp, newAlertRuleResponse(&rules[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Get alert rule
+// @ID          get-alert-rule
+// @Tags  	    alerts
+// @Produce     json
+// @Security    ApiKey
+// @Param       id path int true "alert rule id"
+// @Success     200 {object} alertRuleResponse
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /alerts/{id} [get]
+func (r *alertsRoutes) fetch(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := r.a.Fetch(c.Request.Context(), id)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAlertRuleResponse(rule))
+}
+
+// @Summary     Update alert rule
+// @Description Updating a rule resets its cooldown and crossing state.
+// @ID          update-alert-rule
+// @Tags  	    alerts
+// @Accept      json
+// @Produce     json
+// @Security    ApiKey
+// @Param       id path int true "alert rule id"
+// @Param       request body alertRuleRequest true "Set up alert rule"
+// @Success     200 {object} alertRuleResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /alerts/{id} [put]
+func (r *alertsRoutes) update(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+
+	var request alertRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		processError(c, bindingError(err))
+		return
+	}
+
+	rule, err := request.toRule()
+	if err != nil {
+		processError(c, fmt.Errorf("%w: invalid duration", errInvalidRequest))
+		return
+	}
+	rule.Id = id
+
+	if err := r.a.Update(c.Request.Context(), rule); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAlertRuleResponse(rule))
+}
+
+// @Summary     Delete alert rule
+// @ID          delete-alert-rule
+// @Tags  	    alerts
+// @Security    ApiKey
+// @Param       id path int true "alert rule id"
+// @Success     204
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /alerts/{id} [delete]
+func (r *alertsRoutes) delete(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+
+	if err := r.a.Delete(c.Request.Context(), id); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func ruleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		processError(c, fmt.Errorf("%w: invalid alert rule id", errInvalidRequest))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+const _apiKeyHeader = "X-API-Key"
+
+type auth struct {
+	keys apikeys.Service
+	// required rejects requests without a key; otherwise keys are optional
+	// and only resolved to attribute records to clients.
+	required bool
+}
+
+// authenticate resolves the API key of the request, if any, to a client.
+func (a auth) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := c.GetHeader(_apiKeyHeader)
+		if secret == "" {
+			c.Next()
+			return
+		}
+
+		key, err := a.keys.Authenticate(c.Request.Context(), secret)
+		if err != nil {
+			processError(c, err)
+			return
+		}
+
+		ctx := apikeys.WithClient(c.Request.Context(), key)
+		c.Request = c.Request.WithContext(exchangerates.WithCreator(ctx, key.Client))
+		c.Next()
+	}
+}
+
+// require rejects clients lacking the scope.
+func (a auth) require(scope apikeys.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := apikeys.ClientFrom(c.Request.Context())
+		if !ok {
+			if a.required {
+				processError(c, errAPIKeyRequired)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if !key.HasScope(scope) {
+			processError(c, fmt.Errorf("%w: %s", errMissingScope, scope))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/cache"
+)
+
+// ResponseCache keeps the records served by the read endpoints in memory, so
+// dashboards polling them do not reach the database. Latest rates are
+// dropped when their pair is updated, or after the TTL at the latest in case
+// another instance updated it. Records by identifier are only kept once
+// they are final, as they never change.
+type ResponseCache struct {
+	latest  *cache.LRU[exchangerates.Pair, exchangerates.Record]
+	records *cache.LRU[string, exchangerates.Record]
+
+	// generation changes on every invalidation, so reads racing with an
+	// update do not store the rate they fetched before it.
+	generation atomic.Uint64
+}
+
+func NewResponseCache(size int, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		latest:  cache.New[exchangerates.Pair, exchangerates.Record](size, ttl),
+		records: cache.New[string, exchangerates.Record](size, 0),
+	}
+}
+
+// OnUpdated drops the latest rate of the pair.
+func (rc *ResponseCache) OnUpdated(_ context.Context, record *exchangerates.Record) {
+	rc.generation.Add(1)
+	rc.latest.Delete(exchangerates.Pair{Base: record.Base, Secondary: record.Secondary})
+}
+
+// A nil cache is disabled.
+
+// getLatest also returns the generation to pass to setLatest on a miss,
+// taken before the record is fetched.
+func (rc *ResponseCache) getLatest(pair exchangerates.Pair) (*exchangerates.Record, uint64, bool) {
+	if rc == nil {
+		return nil, 0, false
+	}
+
+	generation := rc.generation.Load()
+	record, ok := rc.latest.Get(pair)
+	return &record, generation, ok
+}
+
+// setLatest stores the record unless a pair was updated since generation.
+func (rc *ResponseCache) setLatest(pair exchangerates.Pair, record *exchangerates.Record, generation uint64) {
+	if rc == nil || rc.generation.Load() != generation {
+		return
+	}
+	rc.latest.Set(pair, *record)
+}
+
+func (rc *ResponseCache) getRecord(identifier string) (*exchangerates.Record, bool) {
+	if rc == nil {
+		return nil, false
+	}
+
+	record, ok := rc.records.Get(identifier)
+	return &record, ok
+}
+
+func (rc *ResponseCache) setRecord(record *exchangerates.Record) {
+	if rc == nil || !record.Status.Final() {
+		return
+	}
+	rc.records.Set(record.Identifier, *record)
+}
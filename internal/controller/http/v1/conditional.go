@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+// _immutable lets clients keep final records, which never change again.
+const _immutable = "private, max-age=31536000, immutable"
+
+// cacheControl lets clients reuse responses for maxAge, or makes them
+// revalidate every time when it is zero. Responses are private since they
+// depend on the API key.
+func cacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return "private, no-cache"
+	}
+	return "private, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// notModified sets the validators of the record and answers 304 when the
+// client's copy is still current, reporting whether it did.
+func notModified(c *gin.Context, record *exchangerates.Record, cacheControl string) bool {
+	tag := etag(record)
+	modified := record.Updated_At.UTC().Truncate(time.Second)
+
+	c.Header("ETag", tag)
+	c.Header("Last-Modified", modified.Format(http.TimeFormat))
+	c.Header("Cache-Control", cacheControl)
+
+	fresh := false
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		// If-Modified-Since is ignored along with If-None-Match, RFC 9110 13.1.3
+		fresh = etagMatches(inm, tag)
+	} else if ims := c.GetHeader("If-Modified-Since"); ims != "" {
+		if since, err := http.ParseTime(ims); err == nil {
+			fresh = !modified.After(since)
+		}
+	}
+
+	if fresh {
+		c.AbortWithStatus(http.StatusNotModified)
+	}
+	return fresh
+}
+
+// etag changes whenever the record, or the record served for a pair, does.
+func etag(record *exchangerates.Record) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(record.Id) + "/" + strconv.FormatInt(record.Updated_At.UnixNano(), 10)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches compares the tags of If-None-Match weakly.
+func etagMatches(header string, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotModified(t *testing.T) {
+	record := &exchangerates.Record{Id: 1, Updated_At: time.Date(2024, 3, 1, 10, 0, 0, 500, time.UTC)}
+	tag := etag(record)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected bool
+	}{
+		{"no validators", nil, false},
+		{"matching etag", map[string]string{"If-None-Match": tag}, true},
+		{"weak etag in a list", map[string]string{"If-None-Match": `"other", W/` + tag}, true},
+		{"any etag", map[string]string{"If-None-Match": "*"}, true},
+		{"stale etag", map[string]string{"If-None-Match": `"other"`}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 10:00:00 GMT"}, true},
+		{"modified since", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 09:59:59 GMT"}, false},
+		{
+			"etag takes precedence",
+			map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Fri, 01 Mar 2024 10:00:00 GMT"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/exchangerates/latest", nil)
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			require.Equal(t, tt.expected, notModified(c, record, "private, no-cache"))
+			require.Equal(t, tag, w.Header().Get("ETag"))
+			require.Equal(t, "Fri, 01 Mar 2024 10:00:00 GMT", w.Header().Get("Last-Modified"))
+			if tt.expected {
+				require.Equal(t, http.StatusNotModified, c.Writer.Status())
+			}
+		})
+	}
+}
+
+type countingService struct {
+	exchangerates.RecodsService
+	record exchangerates.Record
+	calls  int
+}
+
+func (s *countingService) FetchByIdentifier(context.Context, string) (*exchangerates.Record, error) {
+	s.calls++
+	record := s.record
+	return &record, nil
+}
+
+func (s *countingService) FetchLatest(context.Context, string, string) (*exchangerates.Record, error) {
+	s.calls++
+	record := s.record
+	return &record, nil
+}
+
+func TestResponseCache(t *testing.T) {
+	svc := &countingService{record: exchangerates.Record{
+		Id: 1, Identifier: "id", Base: "EUR", Secondary: "USD", Status: exchangerates.StatusCreated, Updated_At: time.Now(),
+	}}
+	rc := NewResponseCache(10, time.Minute)
+
+	handler := gin.New()
+	newExchangeRatesRoutes(handler.Group("/v1"), Services{Records: svc, Cache: rc}, auth{}, Settings{})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	// Pending records are not cached
+	w := get("/v1/exchangerates/id")
+	require.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+	get("/v1/exchangerates/id")
+	require.Equal(t, 2, svc.calls)
+
+	svc.record.Status = exchangerates.StatusUpdated
+	w = get("/v1/exchangerates/id")
+	require.Equal(t, _immutable, w.Header().Get("Cache-Control"))
+	get("/v1/exchangerates/id")
+	require.Equal(t, 3, svc.calls)
+
+	get("/v1/exchangerates/latest?base=eur&secondary=usd")
+	get("/v1/exchangerates/latest?base=EUR&secondary=USD")
+	require.Equal(t, 4, svc.calls)
+
+	rc.OnUpdated(context.Background(), &svc.record)
+	get("/v1/exchangerates/latest?base=EUR&secondary=USD")
+	require.Equal(t, 5, svc.calls)
+
+	// A rate fetched before an update is not stored after it
+	pair := exchangerates.Pair{Base: "EUR", Secondary: "USD"}
+	rc.OnUpdated(context.Background(), &svc.record)
+	_, generation, ok := rc.getLatest(pair)
+	require.False(t, ok)
+	rc.OnUpdated(context.Background(), &svc.record)
+	rc.setLatest(pair, &svc.record, generation)
+	_, _, ok = rc.getLatest(pair)
+	require.False(t, ok)
+}
@@ -1,29 +1,253 @@
 package v1
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/ZakirAvrora/exchange-rate/config"
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
-type response struct {
-	Error string `json:"error" example:"error message"`
+const _problemContentType = "application/problem+json"
+
+// Client errors raised by the handlers and middlewares themselves.
+var (
+	errInvalidRequest    = errors.New("invalid request")
+	errNotFound          = errors.New("not found")
+	errAPIKeyRequired    = errors.New("api key required in " + _apiKeyHeader + " header")
+	errMissingScope      = errors.New("api key lacks the required scope")
+	errAdminDisabled     = errors.New("admin endpoints are disabled")
+	errInvalidAdminToken = errors.New("invalid admin token")
+	errRateLimited       = errors.New("rate limit exceeded, retry later")
+	errNotAcceptable     = errors.New("only text/csv and application/x-ndjson are acceptable")
+)
+
+// problem is an RFC 7807 error response. Code identifies the error and is
+// stable, unlike Detail which is meant for humans.
+type problem struct {
+	Type      string       `json:"type"                 example:"about:blank"`
+	Title     string       `json:"title"                example:"Not Found"`
+	Status    int          `json:"status"               example:"404"`
+	Code      string       `json:"code"                 example:"record_not_found"`
+	Detail    string       `json:"detail,omitempty"     example:"exchange rate was not found"`
+	Instance  string       `json:"instance,omitempty"   example:"/v1/exchangerates/latest"`
+	RequestID string       `json:"request_id,omitempty" example:"5b7c8e0e-3f0e-4a43-9a4c-1b6f3f0a2c11"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError is an invalid field of the request body.
+type fieldError struct {
+	Field   string `json:"field"   example:"pairs[0].base"`
+	Message string `json:"message" example:"is required"`
+}
+
+// errorMapping maps an error, and the errors wrapping it, to a problem.
+// An empty detail is replaced by the error message, for errors that explain
+// what is wrong with the request.
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+	detail string
+}
+
+// _errorMappings are matched in order with errors.Is; unmatched errors are
+// internal errors.
+var _errorMappings = []errorMapping{
+	{errInvalidRequest, http.StatusBadRequest, "invalid_request", ""},
+	{errNotFound, http.StatusNotFound, "not_found", "not found"},
+	{errAPIKeyRequired, http.StatusUnauthorized, "api_key_required", ""},
+	{errMissingScope, http.StatusForbidden, "insufficient_scope", ""},
+	{errAdminDisabled, http.StatusNotFound, "admin_disabled", ""},
+	{errInvalidAdminToken, http.StatusUnauthorized, "invalid_admin_token", ""},
+	{errRateLimited, http.StatusTooManyRequests, "rate_limited", ""},
+	{errNotAcceptable, http.StatusNotAcceptable, "not_acceptable", ""},
+
+	{exchangerates.ErrNoRecord, http.StatusNotFound, "record_not_found", "exchange rate was not found"},
+	{exchangerates.ErrNotSupportedBaseCurrency, http.StatusBadRequest, "unsupported_base_currency", "not supported base currency"},
+	{exchangerates.ErrNotSupportedSecondaryCurrency, http.StatusBadRequest, "unsupported_secondary_currency", "not supported secondary currency"},
+	{exchangerates.ErrDuplicatePair, http.StatusBadRequest, "duplicate_pair", "pair is requested more than once"},
+	{exchangerates.ErrNotQuarantined, http.StatusConflict, "record_not_quarantined", "record is not quarantined"},
+	{exchangerates.ErrNotRetryable, http.StatusConflict, "record_not_retryable", "only failed records can be retried"},
+	{exchangerates.ErrNotPending, http.StatusConflict, "record_not_pending", "only pending records can be cancelled"},
+	{exchangerates.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused", "idempotency key was already used for a different request"},
+	{exchangerates.ErrInvalidIdempotencyKey, http.StatusBadRequest, "invalid_idempotency_key", ""},
+	{exchangerates.ErrInvalidBatchSize, http.StatusBadRequest, "invalid_batch_size", ""},
+	{exchangerates.ErrInvalidWorkerCount, http.StatusBadRequest, "invalid_worker_count", ""},
+	{exchangerates.ErrInvalidStatus, http.StatusBadRequest, "invalid_status", ""},
+	{exchangerates.ErrInvalidRange, http.StatusBadRequest, "invalid_range", ""},
+	{exchangerates.ErrInvalidImport, http.StatusBadRequest, "invalid_import", ""},
+	{exchangerates.ErrInvalidRate, http.StatusBadRequest, "invalid_rate", ""},
+	{exchangerates.ErrInvalidTime, http.StatusBadRequest, "invalid_time", ""},
+	{exchangerates.ErrDuplicateRate, http.StatusConflict, "duplicate_rate", ""},
+
+	{apikeys.ErrNoKey, http.StatusNotFound, "api_key_not_found", "api key was not found"},
+	{apikeys.ErrInvalidKey, http.StatusUnauthorized, "invalid_api_key", "invalid api key"},
+	{apikeys.ErrInvalidClient, http.StatusBadRequest, "invalid_client", ""},
+	{apikeys.ErrInvalidScope, http.StatusBadRequest, "invalid_scope", ""},
+
+	{alerts.ErrNoRule, http.StatusNotFound, "alert_rule_not_found", "alert rule was not found"},
+	{alerts.ErrInvalidKind, http.StatusBadRequest, "invalid_alert_kind", ""},
+	{alerts.ErrInvalidThreshold, http.StatusBadRequest, "invalid_alert_threshold", ""},
+	{alerts.ErrInvalidWindow, http.StatusBadRequest, "invalid_alert_window", ""},
+	{alerts.ErrInvalidCooldown, http.StatusBadRequest, "invalid_alert_cooldown", ""},
+
+	{config.ErrInvalid, http.StatusUnprocessableEntity, "invalid_config", ""},
 }
 
+var _internalError = errorMapping{nil, http.StatusInternalServerError, "internal_error", "internal server error, retry later"}
+
+func lookupError(err error) errorMapping {
+	for _, m := range _errorMappings {
+		if errors.Is(err, m.err) {
+			return m
+		}
+	}
+	return _internalError
+}
+
+// processError answers the request with the problem the error maps to.
 func processError(c *gin.Context, err error) {
-	if errors.Is(err, exchangerates.ErrNoRecord) {
-		errorResponse(c, http.StatusNotFound, "exchange rate for pair was not found")
-	} else if errors.Is(err, exchangerates.ErrNotSupportedBaseCurrency) {
-		errorResponse(c, http.StatusBadRequest, "not supported base currency")
-	} else if errors.Is(err, exchangerates.ErrNotSupportedSecondaryCurrency) {
-		errorResponse(c, http.StatusBadRequest, "not supported secondary currency")
-	} else {
-		errorResponse(c, http.StatusInternalServerError, "we are experiecning internal server errors, retry later")
+	// The request logger reports the cause along with the response status
+	_ = c.Error(err)
+
+	m := lookupError(err)
+
+	p := problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(m.status),
+		Status:    m.status,
+		Code:      m.code,
+		Detail:    m.detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: logger.RequestID(c.Request.Context()),
 	}
+	if p.Detail == "" {
+		p.Detail = err.Error()
+	}
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		p.Errors = reqErr.fields
+	}
+
+	c.Header("Content-Type", _problemContentType)
+	c.AbortWithStatusJSON(m.status, p)
 }
 
-func errorResponse(c *gin.Context, code int, msg string) {
-	c.AbortWithStatusJSON(code, response{msg})
+// errorDetail describes the error like the detail of its problem.
+func errorDetail(err error) string {
+	if m := lookupError(err); m.detail != "" {
+		return m.detail
+	}
+	return err.Error()
+}
+
+// requestError is an invalid request body, with the invalid fields when
+// they are known.
+type requestError struct {
+	fields []fieldError
+	cause  error
+}
+
+func (e *requestError) Error() string {
+	if len(e.fields) > 0 {
+		return "invalid request body"
+	}
+	return "invalid request body: " + e.cause.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return errInvalidRequest
+}
+
+// bindingError turns an error of binding the request body into a
+// requestError.
+func bindingError(err error) error {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		fields         []fieldError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, fieldError{Field: fieldName(fe), Message: validationMessage(fe)})
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		fields = append(fields, fieldError{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)})
+	}
+
+	return &requestError{fields: fields, cause: err}
+}
+
+// fieldName is the path of the field in the request body, such as
+// "pairs[0].base".
+func fieldName(fe validator.FieldError) string {
+	_, name, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return name
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	default:
+		return "a " + t.String()
+	}
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " validation"
+	}
+}
+
+// useJSONFieldNames reports validation errors with the names of the fields
+// in the request body.
+func useJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return f.Name
+		}
+		return name
+	})
 }
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+	}{
+		{
+			"wrapped domain error", fmt.Errorf("fetching exchangerate by identifier is failed: %w", exchangerates.ErrNoRecord),
+			http.StatusNotFound, "record_not_found", "exchange rate was not found",
+		},
+		{
+			"error explaining the request", fmt.Errorf("%w: must be between 1 and 100", exchangerates.ErrInvalidWorkerCount),
+			http.StatusBadRequest, "invalid_worker_count", "invalid worker count: must be between 1 and 100",
+		},
+		{
+			"client error", errRateLimited,
+			http.StatusTooManyRequests, "rate_limited", "rate limit exceeded, retry later",
+		},
+		{
+			"unknown error", fmt.Errorf("connection refused"),
+			http.StatusInternalServerError, "internal_error", "internal server error, retry later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/exchangerates/latest", nil)
+			c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), "request-id"))
+
+			processError(c, tt.err)
+
+			require.Equal(t, tt.expectedStatus, w.Code)
+			require.Equal(t, _problemContentType, w.Header().Get("Content-Type"))
+
+			var p problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			require.Equal(t, problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(tt.expectedStatus),
+				Status:    tt.expectedStatus,
+				Code:      tt.expectedCode,
+				Detail:    tt.expectedDetail,
+				Instance:  "/v1/exchangerates/latest",
+				RequestID: "request-id",
+			}, p)
+		})
+	}
+}
+
+func TestBindingError(t *testing.T) {
+	useJSONFieldNames()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []fieldError
+	}{
+		{
+			"missing nested fields", `{"pairs": [{"base": "EUR"}]}`,
+			[]fieldError{{Field: "pairs[0].secondary", Message: "is required"}},
+		},
+		{
+			"wrong type", `{"pairs": "EUR/USD"}`,
+			[]fieldError{{Field: "pairs", Message: "must be an array"}},
+		},
+		{"malformed body", `{"pairs":`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/exchangerates/refresh:batch", strings.NewReader(tt.body))
+
+			var request doRefreshBatchRequest
+			err := c.ShouldBindJSON(&request)
+			require.Error(t, err)
+
+			processError(c, bindingError(err))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+
+			var p problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			require.Equal(t, "invalid_request", p.Code)
+			require.Equal(t, tt.expectedFields, p.Errors)
+		})
+	}
+}
@@ -1,27 +1,40 @@
 package v1
 
 import (
-	"errors"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	_idempotencyKeyHeader     = "Idempotency-Key"
+	_idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 type translationRoutes struct {
-	t exchangerates.RecodsService
+	t            exchangerates.RecodsService
+	cache        *ResponseCache
+	cacheControl string
 }
 
-func newExchangeRatesRoutes(handler *gin.RouterGroup, t exchangerates.RecodsService) {
-	r := &translationRoutes{t}
+func newExchangeRatesRoutes(handler *gin.RouterGroup, s Services, a auth, settings Settings) {
+	r := &translationRoutes{t: s.Records, cache: s.Cache, cacheControl: cacheControl(settings.CacheMaxAge)}
+	l := s.Limiter
 
 	h := handler.Group("/exchangerates")
 	{
-		h.GET("/:id", r.fetchByID)
-		h.POST("/refresh", r.refresh)
-		h.GET("/latest", r.latest)
+		h.GET("/:id", a.require(apikeys.ScopeRead), rateLimit(l, RateLimitRead),
+			customMethods(map[string]gin.HandlerFunc{"latest:batch": r.latestBatch}, r.fetchByID))
+		h.POST("/refresh", a.require(apikeys.ScopeRefresh), rateLimit(l, RateLimitRefresh), r.refresh)
+		h.POST("/:id", a.require(apikeys.ScopeRefresh), rateLimit(l, RateLimitRefresh),
+			customMethods(map[string]gin.HandlerFunc{"refresh:batch": r.refreshBatch}, nil))
+		h.GET("/latest", a.require(apikeys.ScopeRead), rateLimit(l, RateLimitRead), r.latest)
+		h.GET("/export", a.require(apikeys.ScopeRead), rateLimit(l, RateLimitRead), r.export)
+		h.POST("/:id/retry", a.require(apikeys.ScopeRefresh), rateLimit(l, RateLimitRefresh), r.retry)
 	}
 }
 
@@ -30,30 +43,69 @@ type exchangeResponse struct {
 	UpdateTime time.Time `json:"update_time"`
 }
 
+type recordResponse struct {
+	Rate           float64    `json:"rate"`
+	UpdateTime     time.Time  `json:"update_time"`
+	Status         string     `json:"status"          example:"failed"`
+	FailureCode    string     `json:"failure_code,omitempty"     example:"quota_exhausted"`
+	FailureMessage string     `json:"failure_message,omitempty"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedBy      string     `json:"created_by,omitempty"       example:"billing-service"`
+}
+
 // @Summary     Getting rate by identifier
 // @Description	Display exchange rate value and update time for corresponding identifier request
+// @Description	Failed requests carry a machine-readable failure code and the provider error message
+// @Description	Responses carry an ETag and Last-Modified for conditional requests. Updated and cancelled records
+// @Description	never change again and are marked immutable.
 // @ID          get-rate-by-identifier
 // @Tags  	    exchangerates
 // @Accept      json
+// @Security    ApiKey
 // @Param 		id path string true "unique identifier" Format(uuid)
-// @Success     200 {object} exchangeResponse
-// @Failure     404 {object} response
-// @Failure     500 {object} response
+// @Param       If-None-Match header string false "ETag of the cached response"
+// @Param       If-Modified-Since header string false "Last-Modified of the cached response"
+// @Success     200 {object} recordResponse
+// @Success     304 "record has not changed"
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
 // @Router      /exchangerates/{id} [get]
 func (r *translationRoutes) fetchByID(c *gin.Context) {
 	id := c.Param("id")
-	record, err := r.t.FetchByIdentifier(c.Request.Context(), id)
-	if err != nil {
-		if errors.Is(err, exchangerates.ErrNoRecord) {
-			errorResponse(c, http.StatusNotFound, "exchange rate for identifier was not found")
-		} else {
 
-			errorResponse(c, http.StatusInternalServerError, "we are experiecning internal server errors, retry later")
+	record, ok := r.cache.getRecord(id)
+	if !ok {
+		var err error
+		record, err = r.t.FetchByIdentifier(c.Request.Context(), id)
+		if err != nil {
+			processError(c, err)
+			return
 		}
+		r.cache.setRecord(record)
+	}
+
+	cacheControl := r.cacheControl
+	if record.Status.Final() {
+		cacheControl = _immutable
+	}
+	if notModified(c, record, cacheControl) {
 		return
 	}
 
-	c.JSON(http.StatusOK, exchangeResponse{Rate: record.Rate, UpdateTime: record.Updated_At})
+	c.JSON(http.StatusOK, recordResponse{
+		Rate:           record.Rate,
+		UpdateTime:     record.Updated_At,
+		Status:         record.Status.String(),
+		FailureCode:    string(record.Failure.Code),
+		FailureMessage: record.Failure.Message,
+		Attempts:       record.Attempts,
+		NextAttemptAt:  record.NextAttempt_At,
+		CreatedBy:      record.CreatedBy,
+	})
 }
 
 type doRefreshRequest struct {
@@ -68,20 +120,41 @@ type identiferResponse struct {
 // @Summary     Update exchange rate
 // @Description The service assigns an identifier to the update request.
 // @Description The service updates quotes in the background, i.e. the request handler do not perform the update.
+// @Description Retries with the same Idempotency-Key return the identifier of the first request, marked by the
+// @Description Idempotent-Replayed header; reusing a key for a different pair fails with 422.
 // @ID          update-exchange-rate
 // @Tags  	    exchangerates
 // @Accept      json
 // @Produce     json
+// @Security    ApiKey
 // @Param       request body doRefreshRequest true "Set up currency pair"
-// @Success     200 {object} identiferResponse
-// @Failure     400 {object} response
-// @Failure     500 {object} response
+// @Param       Idempotency-Key header string false "unique key of the request, e.g. a UUID"
+// @Success     201 {object} identiferResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     422 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
 // @Router      /exchangerates/refresh [post]
 func (r *translationRoutes) refresh(c *gin.Context) {
 	var request doRefreshRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("http - v1 - latest", err)
-		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		processError(c, bindingError(err))
+		return
+	}
+
+	if key := c.GetHeader(_idempotencyKeyHeader); key != "" {
+		identifier, replayed, err := r.t.RefreshIdempotent(c.Request.Context(), key, request.Base, request.Secondary)
+		if err != nil {
+			processError(c, err)
+			return
+		}
+
+		if replayed {
+			c.Header(_idempotentReplayedHeader, "true")
+		}
+		c.JSON(http.StatusCreated, identiferResponse{identifier})
 		return
 	}
 
@@ -101,28 +174,47 @@ func (r *translationRoutes) refresh(c *gin.Context) {
 // @Summary     Getting latest exchange rate for currency pair
 // @Description The request specifies the currency pair code.
 // @Description In the response, the service provides the price value and update time.
+// @Description Responses carry an ETag and Last-Modified for conditional requests.
 // @ID          get-latest-rate
 // @Tags  	    exchangerates
 // @Accept      json
+// @Security    ApiKey
 // @Param       base query string true "first currency code of pair" Enums(EUR)
 // @Param       secondary query string true "second currency code of pair" Enums(BTC, MXN, USD, BYR, AED, KZT, RUB, XAU, XAG, LYD)
+// @Param       If-None-Match header string false "ETag of the cached response"
+// @Param       If-Modified-Since header string false "Last-Modified of the cached response"
 // @Success     200 {object} exchangeResponse
-// @Failure     400 {object} response
-// @Failure     404 {object} response
-// @Failure     500 {object} response
+// @Success     304 "rate has not changed"
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
 // @Router      /exchangerates/latest [get]
 func (r *translationRoutes) latest(c *gin.Context) {
-	base := c.Query("base")
-	secondary := c.Query("secondary")
+	pair := exchangerates.Pair{
+		Base:      strings.ToUpper(c.Query("base")),
+		Secondary: strings.ToUpper(c.Query("secondary")),
+	}
 
-	record, err := r.t.FetchLatest(
-		c.Request.Context(),
-		base,
-		secondary,
-	)
+	record, generation, ok := r.cache.getLatest(pair)
+	if !ok {
+		var err error
+		record, err = r.t.FetchLatest(
+			c.Request.Context(),
+			pair.Base,
+			pair.Secondary,
+		)
+
+		if err != nil {
+			processError(c, err)
+			return
+		}
+		r.cache.setLatest(pair, record, generation)
+	}
 
-	if err != nil {
-		processError(c, err)
+	if notModified(c, record, r.cacheControl) {
 		return
 	}
 
@@ -131,3 +223,28 @@ func (r *translationRoutes) latest(c *gin.Context) {
 		UpdateTime: record.Updated_At,
 	})
 }
+
+// @Summary     Retry failed exchange rate update
+// @Description Queues a failed or exhausted update request again, regardless of its remaining attempt budget.
+// @ID          retry-exchange-rate
+// @Tags  	    exchangerates
+// @Produce     json
+// @Security    ApiKey
+// @Param 		id path string true "unique identifier" Format(uuid)
+// @Success     202 {object} identiferResponse
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
+// @Router      /exchangerates/{id}/retry [post]
+func (r *translationRoutes) retry(c *gin.Context) {
+	id := c.Param("id")
+	if err := r.t.Retry(c.Request.Context(), id); err != nil {
+		processError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, identiferResponse{id})
+}
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+// customMethods serves "/{name}:{verb}" paths, which gin cannot route next
+// to the ":id" parameter, and hands other ids to byID, or answers 404 when
+// it is nil.
+func customMethods(methods map[string]gin.HandlerFunc, byID gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h, ok := methods[c.Param("id")]; ok {
+			h(c)
+			return
+		}
+
+		if byID == nil {
+			processError(c, errNotFound)
+			return
+		}
+		byID(c)
+	}
+}
+
+type doRefreshBatchRequest struct {
+	Pairs []doRefreshRequest `json:"pairs" binding:"required,dive"`
+}
+
+type refreshBatchItem struct {
+	Base       string `json:"base"                 example:"EUR"`
+	Secondary  string `json:"secondary"            example:"MXN"`
+	Identifier string `json:"identifier,omitempty"`
+	Code       string `json:"code,omitempty"       example:"unsupported_secondary_currency"`
+	Error      string `json:"error,omitempty"      example:"not supported secondary currency"`
+}
+
+type refreshBatchResponse struct {
+	Items []refreshBatchItem `json:"items"`
+}
+
+// @Summary     Update exchange rates of many pairs
+// @Description The service assigns an identifier to every valid pair and updates them in the background.
+// @Description Invalid or duplicate pairs carry an error instead of an identifier, the other pairs are still queued.
+// @Description A batch counts as a single request against the rate limit.
+// @ID          update-exchange-rate-batch
+// @Tags  	    exchangerates
+// @Accept      json
+// @Produce     json
+// @Security    ApiKey
+// @Param       request body doRefreshBatchRequest true "Up to 20 currency pairs"
+// @Success     200 {object} refreshBatchResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
+// @Router      /exchangerates/refresh:batch [post]
+func (r *translationRoutes) refreshBatch(c *gin.Context) {
+	var request doRefreshBatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		processError(c, bindingError(err))
+		return
+	}
+
+	pairs := make([]exchangerates.Pair, len(request.Pairs))
+	for i, p := range request.Pairs {
+		pairs[i] = exchangerates.Pair{Base: p.Base, Secondary: p.Secondary}
+	}
+
+	items, err := r.t.RefreshBatch(c.Request.Context(), pairs)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := refreshBatchResponse{Items: make([]refreshBatchItem, len(items))}
+	for i, item := range items {
+		resp.Items[i] = refreshBatchItem{
+			Base:       item.Pair.Base,
+			Secondary:  item.Pair.Secondary,
+			Identifier: item.Identifier,
+		}
+		resp.Items[i].Code, resp.Items[i].Error = batchError(item.Err)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+type latestBatchItem struct {
+	Base       string     `json:"base"                  example:"EUR"`
+	Secondary  string     `json:"secondary"             example:"MXN"`
+	Rate       float64    `json:"rate,omitempty"`
+	UpdateTime *time.Time `json:"update_time,omitempty"`
+	Code       string     `json:"code,omitempty"        example:"record_not_found"`
+	Error      string     `json:"error,omitempty"       example:"exchange rate was not found"`
+}
+
+type latestBatchResponse struct {
+	Items []latestBatchItem `json:"items"`
+}
+
+// @Summary     Getting latest exchange rates of many pairs
+// @Description The request lists the currency pairs, the response has an item per pair in the same order.
+// @Description Pairs that are invalid or have no rate yet carry an error instead of the rate.
+// @ID          get-latest-rate-batch
+// @Tags  	    exchangerates
+// @Produce     json
+// @Security    ApiKey
+// @Param       pairs query string true "comma separated pairs, up to 20" example(EUR/USD,EUR/MXN)
+// @Success     200 {object} latestBatchResponse
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
+// @Router      /exchangerates/latest:batch [get]
+func (r *translationRoutes) latestBatch(c *gin.Context) {
+	pairs, err := parsePairs(c.Query("pairs"))
+	if err != nil {
+		processError(c, fmt.Errorf("%w: %s", errInvalidRequest, err))
+		return
+	}
+
+	items, err := r.t.FetchLatestBatch(c.Request.Context(), pairs)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	resp := latestBatchResponse{Items: make([]latestBatchItem, len(items))}
+	for i, item := range items {
+		resp.Items[i] = latestBatchItem{
+			Base:      item.Pair.Base,
+			Secondary: item.Pair.Secondary,
+		}
+		resp.Items[i].Code, resp.Items[i].Error = batchError(item.Err)
+		if item.Record != nil {
+			resp.Items[i].Rate = item.Record.Rate
+			resp.Items[i].UpdateTime = &item.Record.Updated_At
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parsePairs parses pairs such as "EUR/USD,EUR/MXN".
+func parsePairs(s string) ([]exchangerates.Pair, error) {
+	if s == "" {
+		return nil, errors.New("pairs are required")
+	}
+
+	var pairs []exchangerates.Pair
+	for _, p := range strings.Split(s, ",") {
+		base, secondary, ok := strings.Cut(strings.TrimSpace(p), "/")
+		if !ok {
+			return nil, errors.New("pairs must look like EUR/USD")
+		}
+		pairs = append(pairs, exchangerates.Pair{Base: base, Secondary: secondary})
+	}
+
+	return pairs, nil
+}
+
+// batchError returns the code and detail of the problem the error of a batch
+// item maps to.
+func batchError(err error) (string, string) {
+	if err == nil {
+		return "", ""
+	}
+	return lookupError(err).code, errorDetail(err)
+}
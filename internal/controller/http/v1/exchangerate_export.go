@@ -0,0 +1,319 @@
+package v1
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	_mimeCSV    = "text/csv"
+	_mimeNDJSON = "application/x-ndjson"
+
+	// _exportFlushRows is how many rows are written between flushes, each of
+	// which extends the write deadline by _exportWriteTimeout.
+	_exportFlushRows    = 500
+	_exportWriteTimeout = 30 * time.Second
+)
+
+// exportColumn renders a field of the record as a CSV cell and a JSON value.
+type exportColumn struct {
+	text func(*exchangerates.Record) string
+	json func(*exchangerates.Record) any
+}
+
+func textColumn(f func(*exchangerates.Record) string) exportColumn {
+	return exportColumn{text: f, json: func(r *exchangerates.Record) any { return f(r) }}
+}
+
+func timeColumn(f func(*exchangerates.Record) time.Time) exportColumn {
+	return exportColumn{
+		text: func(r *exchangerates.Record) string { return f(r).UTC().Format(time.RFC3339) },
+		json: func(r *exchangerates.Record) any { return f(r).UTC() },
+	}
+}
+
+var _exportColumns = map[string]exportColumn{
+	"identifier": textColumn(func(r *exchangerates.Record) string { return r.Identifier }),
+	"base":       textColumn(func(r *exchangerates.Record) string { return r.Base }),
+	"secondary":  textColumn(func(r *exchangerates.Record) string { return r.Secondary }),
+	"rate": {
+		text: func(r *exchangerates.Record) string { return strconv.FormatFloat(r.Rate, 'f', -1, 64) },
+		json: func(r *exchangerates.Record) any { return r.Rate },
+	},
+	"status":          textColumn(func(r *exchangerates.Record) string { return r.Status.String() }),
+	"created_at":      timeColumn(func(r *exchangerates.Record) time.Time { return r.Created_At }),
+	"updated_at":      timeColumn(func(r *exchangerates.Record) time.Time { return r.Updated_At }),
+	"failure_code":    textColumn(func(r *exchangerates.Record) string { return string(r.Failure.Code) }),
+	"failure_message": textColumn(func(r *exchangerates.Record) string { return r.Failure.Message }),
+	"attempts": {
+		text: func(r *exchangerates.Record) string { return strconv.Itoa(r.Attempts) },
+		json: func(r *exchangerates.Record) any { return r.Attempts },
+	},
+	"created_by": textColumn(func(r *exchangerates.Record) string { return r.CreatedBy }),
+	"source":     textColumn(func(r *exchangerates.Record) string { return r.Source }),
+}
+
+var _defaultExportColumns = []string{"identifier", "base", "secondary", "rate", "status", "updated_at"}
+
+// @Summary     Export exchange rate history
+// @Description Streams the records matching the filters, oldest first, as CSV with a header row or as
+// @Description newline delimited JSON, depending on the Accept header.
+// @ID          export-exchange-rates
+// @Tags  	    exchangerates
+// @Produce     text/csv
+// @Produce     application/x-ndjson
+// @Security    ApiKey
+// @Param       base query string false "first currency code of pair" Enums(EUR)
+// @Param       secondary query string false "second currency code of pair"
+// @Param       status query string false "comma separated statuses" example(updated,quarantined)
+// @Param       from query string false "records updated at or after, RFC 3339 or date" example(2024-03-01)
+// @Param       to query string false "records updated before, RFC 3339 or date" example(2024-04-01T00:00:00Z)
+// @Param       columns query string false "comma separated columns, identifier, base, secondary, rate, status and updated_at by default" example(base,secondary,rate,updated_at)
+// @Success     200 {string} string
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     406 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
+// @Router      /exchangerates/export [get]
+func (r *translationRoutes) export(c *gin.Context) {
+	format := c.NegotiateFormat(_mimeCSV, _mimeNDJSON)
+	if format == "" {
+		processError(c, errNotAcceptable)
+		return
+	}
+
+	filter, err := exportFilter(c)
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	columns, err := exportColumns(c.Query("columns"))
+	if err != nil {
+		processError(c, err)
+		return
+	}
+
+	var w exportWriter
+	if format == _mimeCSV {
+		w = newCSVWriter(c, columns)
+	} else {
+		w = newNDJSONWriter(c, columns)
+	}
+
+	rows := 0
+	err = r.t.Export(c.Request.Context(), filter, func(record *exchangerates.Record) error {
+		if rows == 0 {
+			startExport(c, format)
+			if err := w.header(); err != nil {
+				return err
+			}
+		}
+
+		if err := w.row(record); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%_exportFlushRows == 0 {
+			return w.flush()
+		}
+		return nil
+	})
+
+	if err != nil && rows == 0 {
+		processError(c, err)
+		return
+	}
+
+	if err != nil {
+		// NoReturnErr: the status was sent, the client sees a truncated export
+		_ = c.Error(err)
+		return
+	}
+
+	if rows == 0 {
+		startExport(c, format)
+		if err := w.header(); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	if err := w.flush(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// startExport sends the headers of the export.
+func startExport(c *gin.Context, format string) {
+	ext := "csv"
+	if format == _mimeNDJSON {
+		ext = "ndjson"
+	}
+
+	c.Header("Content-Type", format+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="exchangerates.`+ext+`"`)
+	c.Status(http.StatusOK)
+	extendWriteDeadline(c)
+}
+
+// extendWriteDeadline keeps the server write timeout from cutting exports
+// short, as long as rows keep being written.
+func extendWriteDeadline(c *gin.Context) {
+	// NoReturnErr: writers without deadlines are not cut short either
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(_exportWriteTimeout))
+}
+
+func exportFilter(c *gin.Context) (exchangerates.ExportFilter, error) {
+	filter := exchangerates.ExportFilter{
+		Base:      c.Query("base"),
+		Secondary: c.Query("secondary"),
+	}
+
+	if statuses := c.Query("status"); statuses != "" {
+		for _, name := range strings.Split(statuses, ",") {
+			status, err := exchangerates.ParseStatus(strings.TrimSpace(name))
+			if err != nil {
+				return filter, err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.From, err = parseExportTime(c.Query("from")); err != nil {
+		return filter, fmt.Errorf("%w: from must be an RFC 3339 time or a date", errInvalidRequest)
+	}
+	if filter.To, err = parseExportTime(c.Query("to")); err != nil {
+		return filter, fmt.Errorf("%w: to must be an RFC 3339 time or a date", errInvalidRequest)
+	}
+
+	return filter, nil
+}
+
+// parseExportTime parses RFC 3339 times and dates, which start at midnight UTC.
+func parseExportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func exportColumns(s string) ([]string, error) {
+	if s == "" {
+		return _defaultExportColumns, nil
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := _exportColumns[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", errInvalidRequest, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate column %q", errInvalidRequest, name)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+type exportWriter interface {
+	header() error
+	row(*exchangerates.Record) error
+	flush() error
+}
+
+type csvWriter struct {
+	c       *gin.Context
+	w       *csv.Writer
+	columns []string
+	cells   []string
+}
+
+func newCSVWriter(c *gin.Context, columns []string) *csvWriter {
+	return &csvWriter{c: c, w: csv.NewWriter(c.Writer), columns: columns, cells: make([]string, len(columns))}
+}
+
+func (w *csvWriter) header() error {
+	return w.w.Write(w.columns)
+}
+
+func (w *csvWriter) row(record *exchangerates.Record) error {
+	for i, name := range w.columns {
+		w.cells[i] = _exportColumns[name].text(record)
+	}
+	return w.w.Write(w.cells)
+}
+
+func (w *csvWriter) flush() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	extendWriteDeadline(w.c)
+	return nil
+}
+
+type ndjsonWriter struct {
+	c       *gin.Context
+	w       *bufio.Writer
+	columns []string
+}
+
+func newNDJSONWriter(c *gin.Context, columns []string) *ndjsonWriter {
+	return &ndjsonWriter{c: c, w: bufio.NewWriter(c.Writer), columns: columns}
+}
+
+func (w *ndjsonWriter) header() error {
+	return nil
+}
+
+// row writes the columns in order, which a map would not keep.
+func (w *ndjsonWriter) row(record *exchangerates.Record) error {
+	w.w.WriteByte('{')
+	for i, name := range w.columns {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(_exportColumns[name].json(record))
+		if err != nil {
+			return err
+		}
+
+		w.w.Write(key)
+		w.w.WriteByte(':')
+		w.w.Write(value)
+	}
+	_, err := w.w.WriteString("}\n")
+	return err
+}
+
+func (w *ndjsonWriter) flush() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	extendWriteDeadline(w.c)
+	return nil
+}
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type exportService struct {
+	exchangerates.RecodsService
+	records []exchangerates.Record
+	filter  exchangerates.ExportFilter
+}
+
+func (s *exportService) Export(_ context.Context, filter exchangerates.ExportFilter, fn func(*exchangerates.Record) error) error {
+	s.filter = filter
+	for i := range s.records {
+		if err := fn(&s.records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := &exportService{records: []exchangerates.Record{
+		{Identifier: "a", Base: "EUR", Secondary: "USD", Rate: 1.08, Status: exchangerates.StatusUpdated, Updated_At: updated},
+		{Identifier: "b", Base: "EUR", Secondary: "MXN", Status: exchangerates.StatusFailed, Updated_At: updated,
+			Failure: exchangerates.Failure{Code: "quota_exhausted", Message: `quota, "monthly"`}},
+	}}
+
+	handler := gin.New()
+	newExchangeRatesRoutes(handler.Group("/v1"), Services{Records: svc}, auth{}, Settings{})
+
+	export := func(query, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/exchangerates/export"+query, nil)
+		req.Header.Set("Accept", accept)
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := export("?columns=identifier,rate,failure_message,updated_at", "text/csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, "identifier,rate,failure_message,updated_at\n"+
+		"a,1.08,,2024-03-01T10:00:00Z\n"+
+		`b,0,"quota, ""monthly""",2024-03-01T10:00:00Z`+"\n", w.Body.String())
+
+	w = export("?base=eur&status=updated,failed&from=2024-03-01&to=2024-03-02T00:00:00Z&columns=base,rate,status", "application/x-ndjson")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `{"base":"EUR","rate":1.08,"status":"updated"}`+"\n"+
+		`{"base":"EUR","rate":0,"status":"failed"}`+"\n", w.Body.String())
+	require.Equal(t, exchangerates.ExportFilter{
+		Base:     "eur",
+		Statuses: []exchangerates.Status{exchangerates.StatusUpdated, exchangerates.StatusFailed},
+		From:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+	}, svc.filter)
+
+	svc.records = nil
+	w = export("", "text/csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "identifier,base,secondary,rate,status,updated_at\n", w.Body.String())
+
+	require.Equal(t, http.StatusNotAcceptable, export("", "application/json").Code)
+	require.Equal(t, http.StatusBadRequest, export("?status=done", "text/csv").Code)
+	require.Equal(t, http.StatusBadRequest, export("?columns=rate,secret", "text/csv").Code)
+	require.Equal(t, http.StatusBadRequest, export("?columns=rate,rate", "application/x-ndjson").Code)
+	require.Equal(t, http.StatusBadRequest, export("?from=yesterday", "text/csv").Code)
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+type healthRoutes struct {
+	hc *health.Checker
+}
+
+func newHealthRoutes(handler *gin.Engine, hc *health.Checker) {
+	r := &healthRoutes{hc}
+
+	handler.GET("/health", r.livez)
+	handler.GET("/livez", r.livez)
+	handler.GET("/readyz", r.readyz)
+}
+
+// livez reports whether the process works, i.e. whether it should be restarted.
+func (r *healthRoutes) livez(c *gin.Context) {
+	healthResponse(c, r.hc.Liveness(c.Request.Context()))
+}
+
+// readyz reports whether the service can serve traffic. It fails while a
+// dependency is down and during graceful shutdown.
+func (r *healthRoutes) readyz(c *gin.Context) {
+	healthResponse(c, r.hc.Readiness(c.Request.Context()))
+}
+
+func healthResponse(c *gin.Context, report health.Report) {
+	code := http.StatusOK
+	if !report.Healthy() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
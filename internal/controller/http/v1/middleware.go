@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	_requestIDHeader    = "X-Request-ID"
+	_maxRequestIDLength = 128
+)
+
+// _probeRoutes are polled by orchestrators, successful requests are only
+// logged at debug level.
+var _probeRoutes = map[string]bool{"/health": true, "/livez": true, "/readyz": true}
+
+// requestID reuses the caller's request id, or generates one, echoes it in
+// the response and stores it in the request context.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(_requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(_requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > _maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogger logs every request once it has been served, together with
+// the errors handlers attached to the context.
+func requestLogger(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case _probeRoutes[c.FullPath()] && status < http.StatusBadRequest:
+			level = slog.LevelDebug
+		case _probeRoutes[c.FullPath()]:
+			level = slog.LevelWarn
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if key, ok := apikeys.ClientFrom(c.Request.Context()); ok {
+			attrs = append(attrs, slog.String("client", key.Client))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		l.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// recovery logs a panic of a handler and answers with an internal error.
+func recovery(l *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		l.ErrorContext(c.Request.Context(), "http handler panic",
+			slog.Any("panic", recovered),
+			slog.String("path", c.Request.URL.Path))
+		processError(c, fmt.Errorf("http handler panic: %v", recovered))
+	})
+}
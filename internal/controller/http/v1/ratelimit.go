@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// Route groups sharing a rate limit.
+const (
+	RateLimitRead    = "read"
+	RateLimitRefresh = "refresh"
+)
+
+// rateLimit limits requests of the group per API client, or per IP address
+// for anonymous requests. Requests are let through when the limiter fails.
+func rateLimit(l *ratelimit.Limiter, group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if key, ok := apikeys.ClientFrom(c.Request.Context()); ok {
+			client = "client:" + key.Client
+		}
+
+		res, limited, err := l.Allow(c.Request.Context(), group, client)
+		if err != nil {
+			// NoReturnErr: an unavailable limiter must not take the API down
+			_ = c.Error(err)
+			c.Next()
+			return
+		}
+
+		if !limited {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", res.Limit.Rate, int(res.Limit.Period.Seconds()), res.Limit.Burst))
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			processError(c, errRateLimited)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
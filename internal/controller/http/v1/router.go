@@ -1,9 +1,16 @@
 package v1
 
 import (
-	"net/http"
+	"context"
+	"log/slog"
+	"time"
 
+	"github.com/ZakirAvrora/exchange-rate/internal/alerts"
+	"github.com/ZakirAvrora/exchange-rate/internal/apikeys"
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -12,6 +19,38 @@ import (
 	_ "github.com/ZakirAvrora/exchange-rate/docs"
 )
 
+// Services are the domain services served by the router.
+type Services struct {
+	Records  exchangerates.RecodsService
+	Alerts   alerts.Service
+	Health   *health.Checker
+	Workers  exchangerates.WorkerPool
+	Provider exchangeratesapi.Client
+	APIKeys  apikeys.Service
+	// Limiter limits requests per client, it is optional.
+	Limiter *ratelimit.Limiter
+	// Cache keeps records served by the read endpoints, it is optional.
+	Cache *ResponseCache
+	// Config reloads the configuration, it is optional.
+	Config ConfigReloader
+}
+
+// ConfigReloader reloads the configuration of the process.
+type ConfigReloader interface {
+	// Reload applies the changes that are safe to apply live and returns the
+	// paths of the applied fields and of the fields that require a restart.
+	Reload(ctx context.Context) (applied []string, restart []string, err error)
+}
+
+type Settings struct {
+	// AdminToken protects the admin endpoints along with admin scoped keys.
+	AdminToken string
+	// APIKeysRequired rejects v1 requests without an API key.
+	APIKeysRequired bool
+	// CacheMaxAge lets clients reuse latest rates without revalidating them.
+	CacheMaxAge time.Duration
+}
+
 // NewRouter -.
 // Swagger spec:
 // @title       Currency Exchange Rate API
@@ -19,21 +58,53 @@ import (
 // @version     1.0
 // @host        localhost:8080
 // @BasePath    /v1
-func NewRouter(handler *gin.Engine, t exchangerates.RecodsService) {
+// @securityDefinitions.apikey AdminToken
+// @in          header
+// @name        Authorization
+// @securityDefinitions.apikey ApiKey
+// @in          header
+// @name        X-API-Key
+func NewRouter(handler *gin.Engine, s Services, settings Settings, l *slog.Logger) {
 	// Options
-	handler.Use(gin.Logger())
-	handler.Use(gin.Recovery())
+	useJSONFieldNames()
+	handler.Use(requestID())
+	handler.Use(requestLogger(l))
+	handler.Use(recovery(l))
 
 	// Swagger
 	swaggerHandler := ginSwagger.DisablingWrapHandler(swaggerFiles.Handler, "DISABLE_SWAGGER_HTTP_HANDLER")
 	handler.GET("/swagger/*any", swaggerHandler)
 
-	// Health check
-	handler.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	// Health checks
+	newHealthRoutes(handler, s.Health)
 
 	// Routers
-	h := handler.Group("/v1")
+	a := auth{keys: s.APIKeys, required: settings.APIKeysRequired}
+
+	h := handler.Group("/v1", a.authenticate())
 	{
-		newExchangeRatesRoutes(h, t)
+		newExchangeRatesRoutes(h, s, a, settings)
+		newAlertsRoutes(h, s.Alerts, a)
+		newAdminRoutes(h, s, settings.AdminToken)
+	}
+}
+
+// NewWorkerRouter serves the health checks, the pipeline and the config
+// reload admin endpoints of worker processes.
+func NewWorkerRouter(handler *gin.Engine, s Services, settings Settings, l *slog.Logger) {
+	handler.Use(requestID())
+	handler.Use(requestLogger(l))
+	handler.Use(recovery(l))
+
+	newHealthRoutes(handler, s.Health)
+
+	a := auth{keys: s.APIKeys, required: settings.APIKeysRequired}
+	r := &adminRoutes{s.Records, s.Workers, s.Provider}
+
+	h := handler.Group("/v1", a.authenticate())
+	admin := h.Group("/admin", adminAuth(settings.AdminToken))
+	newPipelineRoutes(admin, r)
+	if s.Config != nil {
+		newConfigRoutes(admin, s.Config)
 	}
 }
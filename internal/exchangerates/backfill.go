@@ -0,0 +1,191 @@
+package exchangerates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	_defaultBackfillInterval = time.Second
+	_day                     = 24 * time.Hour
+)
+
+var ErrQuotaReserve = errors.New("provider quota is down to its reserve")
+
+// Gap is a day, in UTC, without an updated rate of the pair.
+type Gap struct {
+	Pair
+	Day time.Time
+}
+
+// Backfiller fetches the rates of the days missing from the records from
+// the provider. Every fetched rate is stored at once, so a backfill that is
+// interrupted resumes from the days that are still missing.
+type Backfiller struct {
+	repo     RecordsRepo
+	client   exchangeratesapi.Client
+	interval time.Duration
+	reserve  int
+	logger   *slog.Logger
+}
+
+type BackfillOption func(*Backfiller)
+
+// BackfillInterval is the least time between two calls to the provider.
+func BackfillInterval(d time.Duration) BackfillOption {
+	return func(b *Backfiller) {
+		b.interval = d
+	}
+}
+
+// QuotaReserve stops the backfill once the provider reports that many
+// remaining calls or less, leaving them to the refresh workers.
+func QuotaReserve(n int) BackfillOption {
+	return func(b *Backfiller) {
+		b.reserve = n
+	}
+}
+
+func BackfillLogger(l *slog.Logger) BackfillOption {
+	return func(b *Backfiller) {
+		b.logger = l
+	}
+}
+
+func NewBackfiller(repo RecordsRepo, client exchangeratesapi.Client, opts ...BackfillOption) *Backfiller {
+	b := &Backfiller{
+		repo:     repo,
+		client:   client,
+		interval: _defaultBackfillInterval,
+		logger:   slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Gaps returns the days from from to to, both included, that have no updated
+// rate of the pairs, pair by pair and oldest first.
+func (b *Backfiller) Gaps(ctx context.Context, pairs []Pair, from time.Time, to time.Time) ([]Gap, error) {
+	from = from.UTC().Truncate(_day)
+	to = to.UTC().Truncate(_day)
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidRange)
+	}
+	if to.After(time.Now().UTC()) {
+		return nil, fmt.Errorf("%w: to must not be in the future", ErrInvalidRange)
+	}
+
+	var gaps []Gap
+	for _, pair := range pairs {
+		pair = Pair{strings.ToUpper(pair.Base), strings.ToUpper(pair.Secondary)}
+		if err := validPair(pair.Base, pair.Secondary); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", pair.Base, pair.Secondary, err)
+		}
+
+		days, err := b.repo.MissingDays(ctx, pair, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("finding missing days is failed: %w", err)
+		}
+
+		for _, day := range days {
+			gaps = append(gaps, Gap{Pair: pair, Day: day})
+		}
+	}
+
+	return gaps, nil
+}
+
+// Fill fetches and stores the rates of the gaps, calling progress after
+// every gap with the error it failed with, if any. Gaps that fail are left
+// for the next backfill. Fill stops early for ErrQuotaReserve and for
+// errors that would fail every other gap, such as an exhausted quota.
+func (b *Backfiller) Fill(ctx context.Context, gaps []Gap, progress func(Gap, error)) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.Backfill")
+	defer span.End()
+
+	filled := 0
+	for i, gap := range gaps {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return filled, ctx.Err()
+			case <-time.After(b.interval):
+			}
+		}
+
+		if quota := b.client.Quota(); quota.Remaining != nil && *quota.Remaining <= b.reserve {
+			return filled, fmt.Errorf("%w: %d calls remaining", ErrQuotaReserve, *quota.Remaining)
+		}
+
+		err := b.fill(ctx, gap)
+		progress(gap, err)
+		if err == nil {
+			filled++
+			continue
+		}
+
+		switch ClassifyFailure(err).Code {
+		case FailureInvalidAPIKey, FailureQuotaExhausted:
+			span.RecordError(err)
+			return filled, err
+		}
+		if ctx.Err() != nil {
+			return filled, ctx.Err()
+		}
+
+		// NoReturnErr: the day is still missing on the next backfill
+		b.logger.WarnContext(ctx, "backfill of day error", logger.Err(err),
+			slog.String("base", gap.Base), slog.String("secondary", gap.Secondary), slog.String("day", gap.Day.Format(time.DateOnly)))
+	}
+
+	return filled, nil
+}
+
+func (b *Backfiller) fill(ctx context.Context, gap Gap) error {
+	rate, err := b.client.GetHistoricalRate(ctx, gap.Day, gap.Base, gap.Secondary)
+	if err != nil {
+		return err
+	}
+
+	// Rates dated outside of the day are stored at its start, so that they
+	// fill the gap.
+	at := time.Unix(rate.Timestamp, 0).UTC()
+	if at.Before(gap.Day) || !at.Before(gap.Day.Add(_day)) {
+		at = gap.Day
+	}
+
+	v4, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("generating unique identifier is failed: %w", err)
+	}
+
+	_, err = b.repo.Import(ctx, []*Record{{
+		Identifier: v4.String(),
+		Base:       gap.Base,
+		Secondary:  gap.Secondary,
+		Rate:       rate.Value,
+		Status:     StatusUpdated,
+		Created_At: at,
+		Updated_At: at,
+		Source:     SourceBackfilled,
+	}})
+	if err != nil {
+		return fmt.Errorf("storing backfilled rate is failed: %w", err)
+	}
+
+	return nil
+}
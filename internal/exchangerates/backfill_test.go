@@ -0,0 +1,73 @@
+package exchangerates
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi/mockery"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type backfillRepo struct {
+	RecordsRepo
+	missing  []time.Time
+	imported []*Record
+}
+
+func (r *backfillRepo) MissingDays(_ context.Context, _ Pair, _ time.Time, _ time.Time) ([]time.Time, error) {
+	return r.missing, nil
+}
+
+func (r *backfillRepo) Import(_ context.Context, records []*Record) ([]bool, error) {
+	r.imported = append(r.imported, records...)
+	return []bool{true}, nil
+}
+
+func TestBackfiller(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	repo := &backfillRepo{missing: []time.Time{day(1), day(2), day(3), day(4)}}
+
+	remaining := 10
+	client := (&mockery.Client{}).TSetup(t)
+	client.EXPECT().GetHistoricalRate(mock.Anything, day(1), "EUR", "USD").
+		Return(&exchangeratesapi.Rate{Value: 1.08, Timestamp: day(2).Unix() - 1}, nil)
+	client.EXPECT().GetHistoricalRate(mock.Anything, day(2), "EUR", "USD").
+		Return(nil, fmt.Errorf("%w: status code: 402", exchangeratesapi.ErrMaxAllowedAPICalls))
+	client.EXPECT().Quota().Return(exchangeratesapi.Quota{Remaining: &remaining})
+
+	b := NewBackfiller(repo, client, BackfillInterval(time.Millisecond), QuotaReserve(5))
+
+	gaps, err := b.Gaps(context.Background(), []Pair{{"eur", "usd"}}, day(1), day(4))
+	require.NoError(t, err)
+	require.Len(t, gaps, 4)
+	require.Equal(t, Gap{Pair{"EUR", "USD"}, day(1)}, gaps[0])
+
+	_, err = b.Gaps(context.Background(), []Pair{{"EUR", "USD"}}, day(4), day(1))
+	require.ErrorIs(t, err, ErrInvalidRange)
+	_, err = b.Gaps(context.Background(), []Pair{{"EUR", "JPY"}}, day(1), day(4))
+	require.ErrorIs(t, err, ErrNotSupportedSecondaryCurrency)
+
+	var failed []error
+	filled, err := b.Fill(context.Background(), gaps, func(_ Gap, err error) {
+		failed = append(failed, err)
+	})
+	require.ErrorIs(t, err, exchangeratesapi.ErrMaxAllowedAPICalls)
+	require.Equal(t, 1, filled)
+	require.Len(t, failed, 2)
+	require.NoError(t, failed[0])
+
+	require.Len(t, repo.imported, 1)
+	require.Equal(t, SourceBackfilled, repo.imported[0].Source)
+	require.Equal(t, StatusUpdated, repo.imported[0].Status)
+	require.Equal(t, day(2).Add(-time.Second), repo.imported[0].Updated_At)
+
+	// The reserve is kept for the refresh workers
+	remaining = 5
+	filled, err = b.Fill(context.Background(), gaps[2:], func(Gap, error) {})
+	require.ErrorIs(t, err, ErrQuotaReserve)
+	require.Zero(t, filled)
+}
@@ -0,0 +1,20 @@
+package exchangerates
+
+import "context"
+
+type creatorKey struct{}
+
+// WithCreator returns a context carrying the client that makes the request,
+// stored as the CreatedBy of the records it creates.
+func WithCreator(ctx context.Context, client string) context.Context {
+	if client == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, creatorKey{}, client)
+}
+
+// Creator returns the client carried by ctx, if any.
+func Creator(ctx context.Context) string {
+	client, _ := ctx.Value(creatorKey{}).(string)
+	return client
+}
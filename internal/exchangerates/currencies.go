@@ -0,0 +1,72 @@
+package exchangerates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// currencies are the codes of the supported currencies.
+type currencies struct {
+	base      map[string]bool
+	secondary map[string]bool
+}
+
+var supported atomic.Pointer[currencies]
+
+func init() {
+	_ = SetCurrencies(DefaultCurrencies())
+}
+
+// DefaultCurrencies returns the base and secondary currencies supported
+// unless configured otherwise, sorted. Unlike SupportedCurrencies, they do
+// not change with SetCurrencies.
+func DefaultCurrencies() (base []string, secondary []string) {
+	return []string{"EUR"}, []string{"AED", "BTC", "BYR", "KZT", "LYD", "MXN", "RUB", "USD", "XAG", "XAU"}
+}
+
+// SetCurrencies replaces the supported base and secondary currencies. It is
+// safe to call while records are refreshed, pairs are validated against the
+// currencies supported at the time.
+func SetCurrencies(base []string, secondary []string) error {
+	c := &currencies{base: make(map[string]bool), secondary: make(map[string]bool)}
+
+	for _, code := range base {
+		c.base[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+	for _, code := range secondary {
+		c.secondary[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+
+	if len(c.base) == 0 || len(c.secondary) == 0 {
+		return fmt.Errorf("%w: base and secondary currencies are required", ErrInvalidCurrencies)
+	}
+
+	supported.Store(c)
+	return nil
+}
+
+// SupportedCurrencies returns the supported base and secondary currencies,
+// sorted.
+func SupportedCurrencies() (base []string, secondary []string) {
+	c := supported.Load()
+	return sortedCodes(c.base), sortedCodes(c.secondary)
+}
+
+func sortedCodes(codes map[string]bool) []string {
+	sorted := make([]string, 0, len(codes))
+	for code := range codes {
+		sorted = append(sorted, code)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func validBaseCurrency(code string) bool {
+	return supported.Load().base[code]
+}
+
+func validSecondaryCurrency(code string) bool {
+	return supported.Load().secondary[code]
+}
@@ -0,0 +1,30 @@
+package exchangerates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCurrencies(t *testing.T) {
+	base, secondary := SupportedCurrencies()
+	t.Cleanup(func() { require.NoError(t, SetCurrencies(base, secondary)) })
+
+	require.NoError(t, ValidatePair("EUR", "BTC"))
+
+	require.NoError(t, SetCurrencies([]string{"usd"}, []string{" eur", "GBP"}))
+	require.NoError(t, ValidatePair("USD", "GBP"))
+	require.ErrorIs(t, ValidatePair("EUR", "GBP"), ErrNotSupportedBaseCurrency)
+	require.ErrorIs(t, ValidatePair("USD", "BTC"), ErrNotSupportedSecondaryCurrency)
+
+	base2, secondary2 := SupportedCurrencies()
+	require.Equal(t, []string{"USD"}, base2)
+	require.Equal(t, []string{"EUR", "GBP"}, secondary2)
+
+	// The defaults do not follow the supported currencies
+	defaultBase, defaultSecondary := DefaultCurrencies()
+	require.Equal(t, []string{"EUR"}, defaultBase)
+	require.Contains(t, defaultSecondary, "BTC")
+
+	require.ErrorIs(t, SetCurrencies(nil, []string{"USD"}), ErrInvalidCurrencies)
+}
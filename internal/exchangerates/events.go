@@ -0,0 +1,46 @@
+package exchangerates
+
+import "time"
+
+// Event types written to the outbox on every record state change.
+const (
+	EventRecordCreated     = "record.created"
+	EventRecordUpdated     = "record.updated"
+	EventRecordFailed      = "record.failed"
+	EventRecordQuarantined = "record.quarantined"
+	EventRecordRetrying    = "record.retrying"
+	EventRecordCancelled   = "record.cancelled"
+)
+
+// RecordEvent is the payload published to downstream systems.
+type RecordEvent struct {
+	Identifier string    `json:"identifier"`
+	Base       string    `json:"base"`
+	Secondary  string    `json:"secondary"`
+	Rate       float64   `json:"rate"`
+	Status     Status    `json:"status"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	FailureCode    FailureCode `json:"failure_code,omitempty"`
+	FailureMessage string      `json:"failure_message,omitempty"`
+	Attempts       int         `json:"attempts"`
+	NextAttemptAt  *time.Time  `json:"next_attempt_at,omitempty"`
+	CreatedBy      string      `json:"created_by,omitempty"`
+}
+
+func NewRecordEvent(record *Record) RecordEvent {
+	return RecordEvent{
+		Identifier: record.Identifier,
+		Base:       record.Base,
+		Secondary:  record.Secondary,
+		Rate:       record.Rate,
+		Status:     record.Status,
+		UpdatedAt:  record.Updated_At,
+
+		FailureCode:    record.Failure.Code,
+		FailureMessage: record.Failure.Message,
+		Attempts:       record.Attempts,
+		NextAttemptAt:  record.NextAttempt_At,
+		CreatedBy:      record.CreatedBy,
+	}
+}
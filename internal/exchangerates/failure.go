@@ -0,0 +1,93 @@
+package exchangerates
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+)
+
+const _maxFailureMessageLen = 512
+
+type FailureCode string
+
+const (
+	FailureNone                FailureCode = ""
+	FailureInvalidAPIKey       FailureCode = "invalid_api_key"
+	FailureQuotaExhausted      FailureCode = "quota_exhausted"
+	FailureUnsupportedCurrency FailureCode = "unsupported_currency"
+	FailureProviderError       FailureCode = "provider_error"
+	FailureTimeout             FailureCode = "timeout"
+	FailureRejected            FailureCode = "rejected"
+	FailureUnknown             FailureCode = "unknown"
+)
+
+// Failure describes why a record ended in StatusFailed.
+type Failure struct {
+	Code    FailureCode
+	Message string
+}
+
+// Retryable reports whether the failure is likely transient.
+func (f Failure) Retryable() bool {
+	switch f.Code {
+	case FailureProviderError, FailureTimeout, FailureUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// failureClasses maps sentinel errors to failure codes, checked in order.
+var failureClasses = []struct {
+	err  error
+	code FailureCode
+}{
+	{exchangeratesapi.ErrInvalidAPIKey, FailureInvalidAPIKey},
+	{exchangeratesapi.ErrNoAPIKey, FailureInvalidAPIKey},
+	{exchangeratesapi.ErrMaxAllowedAPICalls, FailureQuotaExhausted},
+	{exchangeratesapi.ErrNotSupportedBaseCurrency, FailureUnsupportedCurrency},
+	{exchangeratesapi.ErrNotSupportedTargetCurrency, FailureUnsupportedCurrency},
+	{exchangeratesapi.ErrBaseCurrencyRestricted, FailureUnsupportedCurrency},
+	{context.DeadlineExceeded, FailureTimeout},
+	{httpx.ErrNokResponse, FailureProviderError},
+}
+
+// ClassifyFailure derives the failure code of an error returned while
+// refreshing a record.
+func ClassifyFailure(err error) Failure {
+	return Failure{
+		Code:    classify(err),
+		Message: truncate(err.Error(), _maxFailureMessageLen),
+	}
+}
+
+func classify(err error) FailureCode {
+	for _, class := range failureClasses {
+		if errors.Is(err, class.err) {
+			return class.code
+		}
+	}
+
+	// http.Client timeouts are not wrapped context.DeadlineExceeded errors
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return FailureTimeout
+	}
+
+	return FailureUnknown
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
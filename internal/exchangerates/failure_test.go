@@ -0,0 +1,57 @@
+package exchangerates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode FailureCode
+	}{
+		{"invalid api key", fmt.Errorf("%w: status code: 401", exchangeratesapi.ErrInvalidAPIKey), FailureInvalidAPIKey},
+		{"quota", fmt.Errorf("%w: status code: 402", exchangeratesapi.ErrMaxAllowedAPICalls), FailureQuotaExhausted},
+		{"target currency", exchangeratesapi.ErrNotSupportedTargetCurrency, FailureUnsupportedCurrency},
+		{"nok response", fmt.Errorf("%w: status code: 503", httpx.ErrNokResponse), FailureProviderError},
+		{"context deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), FailureTimeout},
+		{"unknown", errors.New("connection refused"), FailureUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := ClassifyFailure(tt.err)
+			require.Equal(t, tt.expectedCode, failure.Code)
+			require.Equal(t, tt.err.Error(), failure.Message)
+		})
+	}
+}
+
+func TestClassifyFailure_HTTPClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c := httpx.NewClient("test", srv.URL, httpx.WithDefaultHTTPClientWithTimeout(time.Millisecond))
+	err := c.Do(context.Background(), httpx.Call{Method: http.MethodGet})
+
+	require.Equal(t, FailureTimeout, ClassifyFailure(err).Code)
+}
+
+func TestClassifyFailure_TruncatesMessage(t *testing.T) {
+	failure := ClassifyFailure(errors.New(strings.Repeat("é", _maxFailureMessageLen)))
+	require.LessOrEqual(t, len(failure.Message), _maxFailureMessageLen)
+	require.True(t, strings.HasPrefix(strings.Repeat("é", _maxFailureMessageLen), failure.Message))
+}
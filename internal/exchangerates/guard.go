@@ -0,0 +1,105 @@
+package exchangerates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Defaults of the guard options, see MaxDeviation, HistoryWindow and
+// MinSamples.
+const (
+	DefaultGuardMaxDeviation = 10.0
+	DefaultGuardWindow       = 24 * time.Hour
+	DefaultGuardMinSamples   = 3
+)
+
+// Guard rejects provider ticks that deviate implausibly from the recent
+// history of the pair.
+type Guard struct {
+	repo         RecordsRepo
+	maxDeviation float64
+	window       time.Duration
+	minSamples   int
+}
+
+type GuardOption func(*Guard)
+
+// MaxDeviation sets the accepted band, in percent, around the median of the
+// recent history.
+func MaxDeviation(percent float64) GuardOption {
+	return func(g *Guard) {
+		g.maxDeviation = percent
+	}
+}
+
+// HistoryWindow sets how far back the history of the pair is considered.
+func HistoryWindow(window time.Duration) GuardOption {
+	return func(g *Guard) {
+		g.window = window
+	}
+}
+
+// MinSamples sets how many historical rates are required before the band is
+// enforced; with less history every positive rate is accepted.
+func MinSamples(n int) GuardOption {
+	return func(g *Guard) {
+		g.minSamples = n
+	}
+}
+
+func NewGuard(repo RecordsRepo, opts ...GuardOption) *Guard {
+	g := &Guard{
+		repo:         repo,
+		maxDeviation: DefaultGuardMaxDeviation,
+		window:       DefaultGuardWindow,
+		minSamples:   DefaultGuardMinSamples,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Validate returns an error wrapping ErrImplausibleRate when the value is
+// outside the accepted band.
+func (g *Guard) Validate(ctx context.Context, base string, secondary string, value float64) error {
+	if value <= 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%w: %g is not a positive number", ErrImplausibleRate, value)
+	}
+
+	history, err := g.repo.FetchHistory(ctx, base, secondary, time.Now().Add(-g.window))
+	if err != nil {
+		return fmt.Errorf("fetching history for validation is failed: %w", err)
+	}
+
+	if len(history) < g.minSamples || len(history) == 0 {
+		return nil
+	}
+
+	reference := median(history)
+	deviation := math.Abs(value-reference) / reference * 100
+	if deviation > g.maxDeviation {
+		return fmt.Errorf("%w: %g deviates %.2f%% from median %g", ErrImplausibleRate, value, deviation, reference)
+	}
+
+	return nil
+}
+
+func median(records []Record) float64 {
+	rates := make([]float64, 0, len(records))
+	for _, r := range records {
+		rates = append(rates, r.Rate)
+	}
+	sort.Float64s(rates)
+
+	mid := len(rates) / 2
+	if len(rates)%2 == 0 {
+		return (rates[mid-1] + rates[mid]) / 2
+	}
+	return rates[mid]
+}
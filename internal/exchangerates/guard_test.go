@@ -0,0 +1,46 @@
+package exchangerates
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type historyRepo struct {
+	RecordsRepo
+	rates []float64
+}
+
+func (r historyRepo) FetchHistory(_ context.Context, _ string, _ string, _ time.Time) ([]Record, error) {
+	records := make([]Record, 0, len(r.rates))
+	for _, rate := range r.rates {
+		records = append(records, Record{Rate: rate})
+	}
+	return records, nil
+}
+
+func TestGuard_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		history     []float64
+		value       float64
+		expectedErr error
+	}{
+		{"within band", []float64{1.07, 1.08, 1.09}, 1.10, nil},
+		{"outside band", []float64{1.07, 1.08, 1.09}, 1.25, ErrImplausibleRate},
+		{"zero", []float64{1.07, 1.08, 1.09}, 0, ErrImplausibleRate},
+		{"not a number", nil, math.NaN(), ErrImplausibleRate},
+		{"not enough history", []float64{1.08}, 5, nil},
+		{"median ignores outlier", []float64{1.08, 1.08, 9, 1.09}, 1.09, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(historyRepo{rates: tt.history}, MaxDeviation(10), MinSamples(3))
+			require.ErrorIs(t, g.Validate(context.Background(), "EUR", "USD", tt.value), tt.expectedErr)
+		})
+	}
+}
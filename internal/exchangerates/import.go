@@ -0,0 +1,283 @@
+package exchangerates
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+// _importBatchSize is how many rows of an import are stored at once.
+const _importBatchSize = 1000
+
+// ImportMapping names the columns of the CSV header holding the fields of
+// imported rates.
+type ImportMapping struct {
+	Base      string
+	Secondary string
+	// Pair holds pairs such as "EUR/USD", it is used instead of Base and
+	// Secondary when set.
+	Pair string
+	Rate string
+	Time string
+	// TimeLayout parses the time column, RFC 3339 times and dates are
+	// accepted when it is empty.
+	TimeLayout string
+	// Comma is the field delimiter, ',' when it is zero.
+	Comma rune
+}
+
+// DefaultImportMapping reads the base, secondary, rate and time columns.
+func DefaultImportMapping() ImportMapping {
+	return ImportMapping{Base: "base", Secondary: "secondary", Rate: "rate", Time: "time"}
+}
+
+// ImportReport is the outcome of an import. Rows that were not imported are
+// listed in Errors, duplicates included.
+type ImportReport struct {
+	Rows       int
+	Imported   int
+	Duplicates int
+	Errors     []ImportError
+}
+
+// ImportError is the reason a row was not imported. Line is the line of the
+// row in the file, the header being line 1.
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+// importColumns are the indexes of the mapped columns in the header.
+type importColumns struct {
+	base, secondary, pair, rate, time int
+}
+
+// Import stores the rates of the CSV as updated records of SourceImported.
+// Invalid rows and rows whose pair already has a rate at the same time are
+// skipped and reported. Rows are stored in batches as they are read, so a
+// storage error keeps the batches stored before it, which are skipped as
+// duplicates when the file is imported again. Imports publish no events.
+func (r *Recorder) Import(ctx context.Context, src io.Reader, mapping ImportMapping) (*ImportReport, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.Import")
+	defer span.End()
+
+	reader := csv.NewReader(src)
+	reader.ReuseRecord = true
+	if mapping.Comma != 0 {
+		reader.Comma = mapping.Comma
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+	}
+
+	columns, err := mapping.columns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		report  = &ImportReport{}
+		records []*Record
+		lines   []int
+		seen    = make(map[string]int)
+		now     = time.Now()
+	)
+
+	createdBy := Creator(ctx)
+
+	flush := func() error {
+		if len(records) == 0 {
+			return nil
+		}
+
+		inserted, err := r.repo.Import(ctx, records)
+		if err != nil {
+			return fmt.Errorf("importing records is failed: %w", err)
+		}
+
+		for i, ok := range inserted {
+			if ok {
+				report.Imported++
+				continue
+			}
+			report.Duplicates++
+			report.Errors = append(report.Errors, ImportError{lines[i], ErrDuplicateRate})
+		}
+
+		records, lines = records[:0], lines[:0]
+		return nil
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		report.Rows++
+
+		if err != nil {
+			// Rows must have as many fields as the header
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("reading import is failed: %w", err)
+			}
+			report.Errors = append(report.Errors, ImportError{parseErr.StartLine, fmt.Errorf("%w: %s", ErrInvalidImport, parseErr.Err)})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		record, err := importRecord(row, columns, mapping.TimeLayout, now)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportError{line, err})
+			continue
+		}
+		record.CreatedBy = createdBy
+
+		key := record.Base + "/" + record.Secondary + "@" + record.Updated_At.Format(time.RFC3339Nano)
+		if first, ok := seen[key]; ok {
+			report.Duplicates++
+			report.Errors = append(report.Errors, ImportError{line, fmt.Errorf("%w, first on line %d", ErrDuplicateRate, first)})
+			continue
+		}
+		seen[key] = line
+
+		records = append(records, record)
+		lines = append(lines, line)
+
+		if len(records) == _importBatchSize {
+			if err := flush(); err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Rows are read in order but duplicates of stored rates are only known
+	// once they are all read.
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+
+	r.logger.InfoContext(ctx, "rates imported", slog.Int("rows", report.Rows), slog.Int("imported", report.Imported),
+		slog.Int("duplicates", report.Duplicates), slog.Int("errors", len(report.Errors)))
+
+	return report, nil
+}
+
+func (m ImportMapping) columns(header []string) (importColumns, error) {
+	index := func(name string) (int, error) {
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("%w: the header has no %q column", ErrInvalidImport, name)
+	}
+
+	columns := importColumns{base: -1, secondary: -1, pair: -1}
+
+	var err error
+	if m.Pair != "" {
+		if columns.pair, err = index(m.Pair); err != nil {
+			return columns, err
+		}
+	} else {
+		if columns.base, err = index(m.Base); err != nil {
+			return columns, err
+		}
+		if columns.secondary, err = index(m.Secondary); err != nil {
+			return columns, err
+		}
+	}
+
+	if columns.rate, err = index(m.Rate); err != nil {
+		return columns, err
+	}
+	if columns.time, err = index(m.Time); err != nil {
+		return columns, err
+	}
+
+	return columns, nil
+}
+
+// importRecord validates the row and returns its record. Times are stored
+// in UTC with the precision of the database.
+func importRecord(row []string, columns importColumns, layout string, now time.Time) (*Record, error) {
+	var base, secondary string
+	if columns.pair >= 0 {
+		var ok bool
+		base, secondary, ok = strings.Cut(row[columns.pair], "/")
+		if !ok {
+			return nil, fmt.Errorf("%w: pair %q is not like EUR/USD", ErrInvalidImport, row[columns.pair])
+		}
+	} else {
+		base, secondary = row[columns.base], row[columns.secondary]
+	}
+
+	base = strings.ToUpper(strings.TrimSpace(base))
+	secondary = strings.ToUpper(strings.TrimSpace(secondary))
+	if err := validPair(base, secondary); err != nil {
+		return nil, err
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(row[columns.rate]), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return nil, fmt.Errorf("%w: %q is not a positive number", ErrInvalidRate, row[columns.rate])
+	}
+
+	at, err := parseImportTime(strings.TrimSpace(row[columns.time]), layout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTime, row[columns.time])
+	}
+	if at.After(now) {
+		return nil, fmt.Errorf("%w: %q is in the future", ErrInvalidTime, row[columns.time])
+	}
+	at = at.UTC().Truncate(time.Microsecond)
+
+	v4, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("generating unique identifier is failed: %w", err)
+	}
+
+	return &Record{
+		Identifier: v4.String(),
+		Base:       base,
+		Secondary:  secondary,
+		Rate:       rate,
+		Status:     StatusUpdated,
+		Created_At: at,
+		Updated_At: at,
+		Source:     SourceImported,
+	}, nil
+}
+
+func parseImportTime(s string, layout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, s)
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,99 @@
+package exchangerates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type importRepo struct {
+	RecordsRepo
+	stored   map[string]bool
+	imported []*Record
+	batches  int
+}
+
+func (r *importRepo) Import(_ context.Context, records []*Record) ([]bool, error) {
+	r.batches++
+	inserted := make([]bool, len(records))
+	for i, record := range records {
+		key := record.Base + record.Secondary + record.Updated_At.String()
+		if !r.stored[key] {
+			inserted[i] = true
+			r.imported = append(r.imported, record)
+		}
+	}
+	return inserted, nil
+}
+
+func TestRecorder_Import(t *testing.T) {
+	stored := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	repo := &importRepo{stored: map[string]bool{"EURUSD" + stored.String(): true}}
+	r := NewService(repo)
+
+	csv := strings.Join([]string{
+		"Date;Pair;Close",
+		"2023-01-01;eur/usd;1.07",
+		"2023-01-02;EUR/USD;1.06",
+		"2023-01-01;EUR/JPY;140.5",
+		"2023-01-01;EUR/MXN;-1",
+		"yesterday;EUR/MXN;20.1",
+		"2023-01-01;EUR/USD;1.071",
+		"2023-01-01;EUR/MXN",
+		"2999-01-01;EUR/MXN;20.1",
+		"2023-01-01T12:00:00+01:00;EUR/MXN;20.5",
+	}, "\n")
+
+	report, err := r.Import(context.Background(), strings.NewReader(csv), ImportMapping{
+		Pair: "pair", Rate: "close", Time: "date", Comma: ';',
+	})
+	require.NoError(t, err)
+	require.Equal(t, 9, report.Rows)
+	require.Equal(t, 2, report.Imported)
+	require.Equal(t, 2, report.Duplicates)
+
+	lines := make([]int, len(report.Errors))
+	for i, e := range report.Errors {
+		lines[i] = e.Line
+	}
+	require.Equal(t, []int{3, 4, 5, 6, 7, 8, 9}, lines)
+	require.ErrorIs(t, report.Errors[0].Err, ErrDuplicateRate)
+	require.ErrorIs(t, report.Errors[1].Err, ErrNotSupportedSecondaryCurrency)
+	require.ErrorIs(t, report.Errors[2].Err, ErrInvalidRate)
+	require.ErrorIs(t, report.Errors[3].Err, ErrInvalidTime)
+	require.ErrorIs(t, report.Errors[4].Err, ErrDuplicateRate)
+	require.ErrorIs(t, report.Errors[5].Err, ErrInvalidImport)
+	require.ErrorIs(t, report.Errors[6].Err, ErrInvalidTime)
+
+	require.Len(t, repo.imported, 2)
+	require.Equal(t, "EUR", repo.imported[0].Base)
+	require.Equal(t, "USD", repo.imported[0].Secondary)
+	require.Equal(t, StatusUpdated, repo.imported[0].Status)
+	require.Equal(t, SourceImported, repo.imported[0].Source)
+	require.Equal(t, time.Date(2023, 1, 1, 11, 0, 0, 0, time.UTC), repo.imported[1].Updated_At)
+
+	_, err = r.Import(context.Background(), strings.NewReader("base,secondary,rate\n"), DefaultImportMapping())
+	require.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestRecorder_Import_Batches(t *testing.T) {
+	repo := &importRepo{}
+	r := NewService(repo)
+
+	var csv strings.Builder
+	csv.WriteString("base,secondary,rate,time\n")
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < _importBatchSize+1; i++ {
+		fmt.Fprintf(&csv, "EUR,USD,1.1,%s\n", day.Add(time.Duration(i)*time.Hour).Format(time.RFC3339))
+	}
+
+	report, err := r.Import(context.Background(), strings.NewReader(csv.String()), DefaultImportMapping())
+	require.NoError(t, err)
+	require.Equal(t, _importBatchSize+1, report.Imported)
+	require.Equal(t, 2, repo.batches)
+	require.Len(t, repo.imported, _importBatchSize+1)
+}
@@ -1,19 +1,124 @@
 package exchangerates
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type RecodsService interface {
 	Refresh(context.Context, string, string) (string, error)
+	// RefreshIdempotent is Refresh, except that retries with the same key
+	// return the identifier of the first request and replayed is true.
+	RefreshIdempotent(ctx context.Context, key string, base string, secondary string) (identifier string, replayed bool, err error)
 	FetchByIdentifier(context.Context, string) (*Record, error)
 	FetchLatest(context.Context, string, string) (*Record, error)
+	// RefreshBatch and FetchLatestBatch return an item per pair, in order.
+	// They fail as a whole only for ErrInvalidBatchSize and storage errors.
+	RefreshBatch(context.Context, []Pair) ([]BatchItem, error)
+	FetchLatestBatch(context.Context, []Pair) ([]BatchItem, error)
+	// Export calls fn for every record matching the filter, oldest first,
+	// without loading them all in memory. It stops at the first error of fn.
+	Export(ctx context.Context, filter ExportFilter, fn func(*Record) error) error
+	// Import stores the rates of a CSV file, see ImportMapping. It fails as a
+	// whole only for ErrInvalidImport and storage errors.
+	Import(ctx context.Context, src io.Reader, mapping ImportMapping) (*ImportReport, error)
 	ShiftUpdated(context.Context, string, float64) error
-	ShiftFailed(context.Context, string) error
+	// ShiftFailed stores the classified refresh error on the record.
+	ShiftFailed(context.Context, string, error) error
+	ShiftQuarantined(context.Context, string, float64) error
+	FetchQuarantined(context.Context) ([]Record, error)
+	AcceptQuarantined(context.Context, string) error
+	RejectQuarantined(context.Context, string) error
+	// Retry re-queues a failed or exhausted record.
+	Retry(context.Context, string) error
+	// RescheduleDue re-queues failed records whose next attempt is due and
+	// returns how many were queued.
+	RescheduleDue(context.Context) (int, error)
+	// DispatchPending queues pending records claimed from the database and
+	// returns how many were queued, see WithDurableQueue.
+	DispatchPending(context.Context) (int, error)
+	// Cancel stops a created record, or a failed one waiting to be retried,
+	// from being processed.
+	Cancel(context.Context, string) error
+	// QueueLen returns the number of records waiting for a worker of this
+	// process.
+	QueueLen() int
+	CountByStatus(context.Context) (map[Status]int, error)
+	// FailureCounts groups records that failed since the given time by reason.
+	FailureCounts(ctx context.Context, since time.Time) ([]FailureCount, error)
 }
 
 type RecordsRepo interface {
 	Insert(context.Context, *Record) error
+	// InsertIdempotent inserts the record unless the client already used the
+	// key, in which case the stored key is returned and nothing is inserted.
+	// Expired keys are forgotten.
+	InsertIdempotent(context.Context, *Record, IdempotencyKey) (*IdempotencyKey, error)
+	// InsertBatch inserts all records in a single transaction.
+	InsertBatch(context.Context, []*Record) error
 	FetchByIdentifier(context.Context, string) (*Record, error)
 	FetchLatest(context.Context, string, string) (*Record, error)
+	// FetchLatestBatch returns the latest updated record of every pair that
+	// has one, in no particular order.
+	FetchLatestBatch(context.Context, []Pair) ([]Record, error)
+	// Export streams the records matching the filter to fn, oldest first.
+	Export(ctx context.Context, filter ExportFilter, fn func(*Record) error) error
+	// Import inserts the records at once, except those whose pair already has
+	// an updated record at the same time. It reports whether each record was
+	// inserted.
+	Import(ctx context.Context, records []*Record) ([]bool, error)
+	// MissingDays returns the days from from to to, both included, without
+	// an updated record of the pair, oldest first.
+	MissingDays(ctx context.Context, pair Pair, from time.Time, to time.Time) ([]time.Time, error)
+	// FetchHistory returns updated records of the pair since the given time, oldest first.
+	FetchHistory(ctx context.Context, base string, secondary string, since time.Time) ([]Record, error)
+	// ShiftUpdated, ShiftFailed and ShiftQuarantined return ErrNotPending
+	// when the record is not in StatusCreated, e.g. it was cancelled.
 	ShiftUpdated(context.Context, string, float64) error
-	ShiftFailed(context.Context, string) error
+	// ShiftFailed schedules the next attempt according to the policy, or
+	// moves the record to StatusExhausted once the budget is spent.
+	ShiftFailed(context.Context, string, Failure, RetryPolicy) error
+	ShiftQuarantined(context.Context, string, float64) error
+	FetchByStatus(context.Context, Status) ([]Record, error)
+	// AcceptQuarantined and RejectQuarantined return ErrNotQuarantined when
+	// the record is not in StatusQuarantined.
+	AcceptQuarantined(context.Context, string) error
+	RejectQuarantined(context.Context, string, Failure) error
+	// ClaimDueRetries moves up to limit failed records whose next attempt is
+	// due back to StatusCreated and returns them, claimed for lease.
+	ClaimDueRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Record, error)
+	// ClaimPending returns up to limit pending records, oldest first, that
+	// are not claimed yet or whose claim expired, and claims them for lease.
+	ClaimPending(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Record, error)
+	// ClaimRetry moves a failed or exhausted record back to StatusCreated,
+	// claimed for lease unless it is zero, or returns ErrNotRetryable.
+	ClaimRetry(ctx context.Context, identifier string, lease time.Duration) (*Record, error)
+	// Cancel moves a pending record to StatusCancelled, or returns ErrNotPending.
+	Cancel(context.Context, string) error
+	CountByStatus(context.Context) (map[Status]int, error)
+	FailureCounts(ctx context.Context, since time.Time) ([]FailureCount, error)
+}
+
+// WorkerPool controls the workers processing queued records.
+type WorkerPool interface {
+	// Pause stops workers from taking new records, in-flight records finish.
+	Pause()
+	Resume()
+	Paused() bool
+	// Resize starts or stops workers until n are running, or returns
+	// ErrInvalidWorkerCount.
+	Resize(n int) error
+	Size() int
+	InFlight() []InFlight
+}
+
+// RateValidator decides whether a provider tick is plausible enough to be stored.
+type RateValidator interface {
+	Validate(ctx context.Context, base string, secondary string, value float64) error
+}
+
+// UpdateListener is notified after a record moved to StatusUpdated.
+type UpdateListener interface {
+	OnUpdated(context.Context, *Record)
 }
@@ -2,57 +2,232 @@ package exchangerates
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	_rescheduleBatchSize  = 100
+	_maxIdempotencyKeyLen = 255
+	_maxBatchSize         = 20
+)
+
+// Defaults of the recorder options, see WithIdempotencyTTL, WithQueueLease
+// and WithQueueSize.
+const (
+	DefaultIdempotencyTTL = 24 * time.Hour
+	DefaultQueueLease     = 5 * time.Minute
+	DefaultQueueSize      = 5
 )
 
 type Recorder struct {
-	repo  RecordsRepo
-	queue chan Record
+	repo        RecordsRepo
+	queue       chan Record
+	listeners   []UpdateListener
+	retryPolicy RetryPolicy
+	logger      *slog.Logger
+	// idempotencyTTL is how long idempotency keys are remembered.
+	idempotencyTTL time.Duration
+	// durable leaves new pending records in the database, where workers of
+	// any process claim them for lease.
+	durable bool
+	lease   time.Duration
+}
+
+type Option func(*Recorder)
+
+func WithUpdateListener(l UpdateListener) Option {
+	return func(r *Recorder) {
+		r.listeners = append(r.listeners, l)
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(r *Recorder) {
+		r.retryPolicy = policy
+	}
+}
+
+// WithIdempotencyTTL sets how long idempotency keys are remembered.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(r *Recorder) {
+		r.idempotencyTTL = ttl
+	}
 }
 
-func NewService(repo RecordsRepo) *Recorder {
-	return &Recorder{
-		repo:  repo,
-		queue: make(chan Record, 5),
+// WithDurableQueue leaves new and retried records in the database instead of
+// queueing them, for API processes whose workers run elsewhere. Workers claim
+// them with DispatchPending; a claimed record that is still pending once the
+// lease expired, because its worker died, is claimed again.
+func WithDurableQueue(lease time.Duration) Option {
+	return func(r *Recorder) {
+		r.durable = true
+		r.lease = lease
+	}
+}
+
+// WithQueueLease sets how long records queued by this process are claimed
+// for. A record still pending once its lease expired, because the process
+// stopped before processing it, is claimed again with DispatchPending.
+func WithQueueLease(lease time.Duration) Option {
+	return func(r *Recorder) {
+		r.lease = lease
+	}
+}
+
+// WithQueueSize sets how many records may wait for a worker of this process.
+func WithQueueSize(n int) Option {
+	return func(r *Recorder) {
+		r.queue = make(chan Record, n)
+	}
+}
+
+func WithLogger(l *slog.Logger) Option {
+	return func(r *Recorder) {
+		r.logger = l
+	}
+}
+
+func NewService(repo RecordsRepo, opts ...Option) *Recorder {
+	r := &Recorder{
+		repo:           repo,
+		queue:          make(chan Record, DefaultQueueSize),
+		retryPolicy:    DefaultRetryPolicy(),
+		logger:         slog.Default(),
+		idempotencyTTL: DefaultIdempotencyTTL,
+		lease:          DefaultQueueLease,
+	}
+
+	for _, opt := range opts {
+		opt(r)
 	}
+
+	return r
 }
 
 func (r *Recorder) Queue() <-chan Record {
 	return r.queue
 }
 
+// QueueLen returns the number of records waiting for a worker.
+func (r *Recorder) QueueLen() int {
+	return len(r.queue)
+}
+
 func (r *Recorder) Refresh(ctx context.Context, base string, secondary string) (string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.Refresh")
+	defer span.End()
+
+	record, err := r.newRecord(ctx, base, secondary)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.repo.Insert(ctx, record); err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("refresh request is failed: %w", err)
+	}
+
+	span.SetAttributes(attribute.String("record.identifier", record.Identifier))
+
+	r.dispatch(record)
+
+	return record.Identifier, nil
+}
+
+func (r *Recorder) RefreshIdempotent(ctx context.Context, key string, base string, secondary string) (string, bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.RefreshIdempotent")
+	defer span.End()
+
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > _maxIdempotencyKeyLen {
+		return "", false, fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidIdempotencyKey, _maxIdempotencyKeyLen)
+	}
+
+	record, err := r.newRecord(ctx, base, secondary)
+	if err != nil {
+		return "", false, err
+	}
+
+	idempotencyKey := IdempotencyKey{
+		Client:      record.CreatedBy,
+		Key:         key,
+		RequestHash: refreshHash(record.Base, record.Secondary),
+		Identifier:  record.Identifier,
+		Expires_At:  time.Now().Add(r.idempotencyTTL),
+	}
+
+	stored, err := r.repo.InsertIdempotent(ctx, record, idempotencyKey)
+	if err != nil {
+		span.RecordError(err)
+		return "", false, fmt.Errorf("refresh request is failed: %w", err)
+	}
+
+	if stored != nil {
+		if stored.RequestHash != idempotencyKey.RequestHash {
+			return "", false, ErrIdempotencyKeyReused
+		}
+
+		span.SetAttributes(
+			attribute.String("record.identifier", stored.Identifier),
+			attribute.Bool("idempotency.replayed", true),
+		)
+		return stored.Identifier, true, nil
+	}
+
+	span.SetAttributes(attribute.String("record.identifier", record.Identifier))
+
+	r.dispatch(record)
+
+	return record.Identifier, false, nil
+}
+
+// newRecord validates the pair and returns a created record of the request.
+func (r *Recorder) newRecord(ctx context.Context, base string, secondary string) (*Record, error) {
 	base = strings.ToUpper(base)
 	secondary = strings.ToUpper(secondary)
 
 	if err := validPair(base, secondary); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	v4, err := uuid.NewRandom()
 	if err != nil {
-		log.Fatal("cannot generate unique identifier")
+		return nil, fmt.Errorf("generating unique identifier is failed: %w", err)
 	}
-	identifier := v4.String()
 
 	record := &Record{
-		Identifier: identifier,
-		Base:       base,
-		Secondary:  secondary,
-		Status:     StatusCreated,
+		Identifier:  v4.String(),
+		Base:        base,
+		Secondary:   secondary,
+		Status:      StatusCreated,
+		TraceParent: tracing.TraceParent(ctx),
+		RequestID:   logger.RequestID(ctx),
 	}
-
-	if err := r.repo.Insert(ctx, record); err != nil {
-		return "", fmt.Errorf("refresh request is failed: %w", err)
+	record.CreatedBy = Creator(ctx)
+	// Records queued at once are claimed by this process
+	if !r.durable {
+		claimedUntil := time.Now().Add(r.lease)
+		record.Claimed_Until = &claimedUntil
 	}
 
-	r.queue <- *record
+	return record, nil
+}
 
-	return identifier, nil
+// refreshHash identifies the refresh request an idempotency key was used for.
+func refreshHash(base string, secondary string) string {
+	sum := sha256.Sum256([]byte(base + "/" + secondary))
+	return hex.EncodeToString(sum[:])
 }
 
 func (r *Recorder) FetchByIdentifier(ctx context.Context, identifier string) (*Record, error) {
@@ -78,14 +253,350 @@ func (r *Recorder) FetchLatest(ctx context.Context, base string, secondary strin
 	return record, nil
 }
 
+func (r *Recorder) RefreshBatch(ctx context.Context, pairs []Pair) ([]BatchItem, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.RefreshBatch")
+	defer span.End()
+
+	items, err := batchItems(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(items))
+	for i := range items {
+		if items[i].Err != nil {
+			continue
+		}
+
+		record, err := r.newRecord(ctx, items[i].Pair.Base, items[i].Pair.Secondary)
+		if err != nil {
+			return nil, err
+		}
+		items[i].Identifier = record.Identifier
+		records = append(records, record)
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", len(items)), attribute.Int("batch.created", len(records)))
+
+	if len(records) == 0 {
+		return items, nil
+	}
+
+	if err := r.repo.InsertBatch(ctx, records); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("batch refresh request is failed: %w", err)
+	}
+
+	for _, record := range records {
+		r.dispatch(record)
+	}
+
+	return items, nil
+}
+
+func (r *Recorder) FetchLatestBatch(ctx context.Context, pairs []Pair) ([]BatchItem, error) {
+	items, err := batchItems(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make([]Pair, 0, len(items))
+	for _, item := range items {
+		if item.Err == nil {
+			valid = append(valid, item.Pair)
+		}
+	}
+
+	if len(valid) == 0 {
+		return items, nil
+	}
+
+	records, err := r.repo.FetchLatestBatch(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest exchangerates is failed: %w", err)
+	}
+
+	latest := make(map[Pair]*Record, len(records))
+	for i := range records {
+		latest[Pair{records[i].Base, records[i].Secondary}] = &records[i]
+	}
+
+	for i := range items {
+		if items[i].Err != nil {
+			continue
+		}
+
+		if record, ok := latest[items[i].Pair]; ok {
+			items[i].Record = record
+		} else {
+			items[i].Err = ErrNoRecord
+		}
+	}
+
+	return items, nil
+}
+
+// batchItems normalizes the pairs of a batch request and sets the error of
+// invalid and duplicate ones.
+func batchItems(pairs []Pair) ([]BatchItem, error) {
+	if len(pairs) == 0 || len(pairs) > _maxBatchSize {
+		return nil, fmt.Errorf("%w: must be between 1 and %d pairs", ErrInvalidBatchSize, _maxBatchSize)
+	}
+
+	items := make([]BatchItem, len(pairs))
+	seen := make(map[Pair]bool, len(pairs))
+
+	for i, pair := range pairs {
+		pair = Pair{
+			Base:      strings.ToUpper(strings.TrimSpace(pair.Base)),
+			Secondary: strings.ToUpper(strings.TrimSpace(pair.Secondary)),
+		}
+		items[i].Pair = pair
+
+		if err := validPair(pair.Base, pair.Secondary); err != nil {
+			items[i].Err = err
+		} else if seen[pair] {
+			items[i].Err = ErrDuplicatePair
+		}
+		seen[pair] = true
+	}
+
+	return items, nil
+}
+
+func (r *Recorder) Export(ctx context.Context, filter ExportFilter, fn func(*Record) error) error {
+	ctx, span := tracing.Tracer().Start(ctx, "exchangerates.Export")
+	defer span.End()
+
+	filter.Base = strings.ToUpper(strings.TrimSpace(filter.Base))
+	filter.Secondary = strings.ToUpper(strings.TrimSpace(filter.Secondary))
+
+	if filter.Base != "" && !validBaseCurrency(filter.Base) {
+		return ErrNotSupportedBaseCurrency
+	}
+	if filter.Secondary != "" && !validSecondaryCurrency(filter.Secondary) {
+		return ErrNotSupportedSecondaryCurrency
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidRange)
+	}
+
+	if err := r.repo.Export(ctx, filter, fn); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("exporting records is failed: %w", err)
+	}
+	return nil
+}
+
 func (r *Recorder) ShiftUpdated(ctx context.Context, identifeir string, rate float64) error {
-	err := r.repo.ShiftUpdated(ctx, identifeir, rate)
-	return fmt.Errorf("shifting status to updated error: %w", err)
+	if err := r.repo.ShiftUpdated(ctx, identifeir, rate); err != nil {
+		return fmt.Errorf("shifting status to updated error: %w", err)
+	}
+
+	r.notifyUpdated(ctx, identifeir)
+	return nil
+}
+
+func (r *Recorder) ShiftFailed(ctx context.Context, identifeir string, cause error) error {
+	failure := ClassifyFailure(cause)
+
+	policy := r.retryPolicy
+	if !failure.Retryable() {
+		policy = RetryPolicy{}
+	}
+
+	if err := r.repo.ShiftFailed(ctx, identifeir, failure, policy); err != nil {
+		return fmt.Errorf("shifting status to failed error: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) ShiftQuarantined(ctx context.Context, identifeir string, rate float64) error {
+	if err := r.repo.ShiftQuarantined(ctx, identifeir, rate); err != nil {
+		return fmt.Errorf("shifting status to quarantined error: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) FetchQuarantined(ctx context.Context) ([]Record, error) {
+	records, err := r.repo.FetchByStatus(ctx, StatusQuarantined)
+	if err != nil {
+		return nil, fmt.Errorf("fetching quarantined records is failed: %w", err)
+	}
+	return records, nil
+}
+
+// AcceptQuarantined stores the quarantined rate as the updated rate.
+func (r *Recorder) AcceptQuarantined(ctx context.Context, identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+
+	if err := r.repo.AcceptQuarantined(ctx, identifier); err != nil {
+		return fmt.Errorf("accepting quarantined record is failed: %w", r.stateError(ctx, identifier, err, ErrNotQuarantined))
+	}
+
+	r.notifyUpdated(ctx, identifier)
+	return nil
+}
+
+// RejectQuarantined discards the quarantined rate and fails the record.
+func (r *Recorder) RejectQuarantined(ctx context.Context, identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+
+	failure := Failure{Code: FailureRejected, Message: "quarantined rate was rejected by an operator"}
+	if err := r.repo.RejectQuarantined(ctx, identifier, failure); err != nil {
+		return fmt.Errorf("rejecting quarantined record is failed: %w", r.stateError(ctx, identifier, err, ErrNotQuarantined))
+	}
+	return nil
+}
+
+func (r *Recorder) Retry(ctx context.Context, identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+
+	var lease time.Duration
+	if !r.durable {
+		lease = r.lease
+	}
+
+	record, err := r.repo.ClaimRetry(ctx, identifier, lease)
+	if err != nil {
+		return fmt.Errorf("retrying record is failed: %w", r.stateError(ctx, identifier, err, ErrNotRetryable))
+	}
+
+	if r.durable {
+		return nil
+	}
+	return r.enqueue(ctx, *record)
+}
+
+// RescheduleDue claims as many due retries as the queue has room for and
+// queues them. Claimed records that could not be queued are claimed again
+// with DispatchPending once their lease expired.
+func (r *Recorder) RescheduleDue(ctx context.Context) (int, error) {
+	free := min(cap(r.queue)-len(r.queue), _rescheduleBatchSize)
+	if free <= 0 {
+		return 0, nil
+	}
+
+	records, err := r.repo.ClaimDueRetries(ctx, time.Now(), free, r.lease)
+	if err != nil {
+		return 0, fmt.Errorf("claiming due retries is failed: %w", err)
+	}
+
+	for i, record := range records {
+		if err := r.enqueue(ctx, record); err != nil {
+			return i, err
+		}
+	}
+
+	return len(records), nil
+}
+
+// DispatchPending claims as many pending records as the queue has room for
+// and queues them, see WithDurableQueue.
+func (r *Recorder) DispatchPending(ctx context.Context) (int, error) {
+	free := cap(r.queue) - len(r.queue)
+	if free <= 0 {
+		return 0, nil
+	}
+
+	records, err := r.repo.ClaimPending(ctx, time.Now(), free, r.lease)
+	if err != nil {
+		return 0, fmt.Errorf("claiming pending records is failed: %w", err)
+	}
+
+	for i, record := range records {
+		if err := r.enqueue(ctx, record); err != nil {
+			return i, err
+		}
+	}
+
+	return len(records), nil
+}
+
+// dispatch queues a new pending record, unless workers claim them from the
+// database. A record that does not fit in the queue, e.g. while workers are
+// paused, stays leased in the database, where the queue poller claims it
+// once the lease expires.
+func (r *Recorder) dispatch(record *Record) {
+	if r.durable {
+		return
+	}
+	select {
+	case r.queue <- *record:
+	default:
+		r.logger.Warn("queue is full, record is left for the queue poller",
+			slog.String("identifier", record.Identifier))
+	}
+}
+
+func (r *Recorder) enqueue(ctx context.Context, record Record) error {
+	select {
+	case r.queue <- record:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("queueing record is failed: %w", ctx.Err())
+	}
+}
+
+func (r *Recorder) Cancel(ctx context.Context, identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+
+	if err := r.repo.Cancel(ctx, identifier); err != nil {
+		return fmt.Errorf("cancelling record is failed: %w", r.stateError(ctx, identifier, err, ErrNotPending))
+	}
+	return nil
+}
+
+func (r *Recorder) CountByStatus(ctx context.Context) (map[Status]int, error) {
+	counts, err := r.repo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("counting records by status is failed: %w", err)
+	}
+	return counts, nil
+}
+
+func (r *Recorder) FailureCounts(ctx context.Context, since time.Time) ([]FailureCount, error) {
+	counts, err := r.repo.FailureCounts(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("counting failures is failed: %w", err)
+	}
+	return counts, nil
+}
+
+// stateError tells a missing record apart from one in another status, which
+// the repo reports both as stateErr.
+func (r *Recorder) stateError(ctx context.Context, identifier string, err error, stateErr error) error {
+	if !errors.Is(err, stateErr) {
+		return err
+	}
+
+	if _, fetchErr := r.repo.FetchByIdentifier(ctx, identifier); errors.Is(fetchErr, ErrNoRecord) {
+		return ErrNoRecord
+	}
+	return err
+}
+
+func (r *Recorder) notifyUpdated(ctx context.Context, identifier string) {
+	if len(r.listeners) == 0 {
+		return
+	}
+
+	record, err := r.repo.FetchByIdentifier(ctx, identifier)
+	if err != nil {
+		// NoReturnErr: the update itself succeeded
+		r.logger.ErrorContext(ctx, "notifying update listeners error",
+			logger.Err(err), slog.String("identifier", identifier))
+		return
+	}
+
+	for _, l := range r.listeners {
+		l.OnUpdated(ctx, record)
+	}
 }
 
-func (r *Recorder) ShiftFailed(ctx context.Context, identifeir string) error {
-	err := r.repo.ShiftFailed(ctx, identifeir)
-	return fmt.Errorf("shifting status to failed error: %w", err)
+// ValidatePair reports whether the currency pair is supported by the service.
+func ValidatePair(base, secondary string) error {
+	return validPair(strings.ToUpper(base), strings.ToUpper(secondary))
 }
 
 func validPair(base, secondary string) error {
@@ -0,0 +1,302 @@
+package exchangerates
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+	"github.com/stretchr/testify/require"
+)
+
+type failedRepo struct {
+	RecordsRepo
+	failure Failure
+	policy  RetryPolicy
+}
+
+func (r *failedRepo) ShiftFailed(_ context.Context, _ string, failure Failure, policy RetryPolicy) error {
+	r.failure = failure
+	r.policy = policy
+	return nil
+}
+
+func TestRecorder_ShiftFailed_RetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedPolicy RetryPolicy
+	}{
+		{"transient provider error is retried", fmt.Errorf("%w: status code: 503", httpx.ErrNokResponse), policy},
+		{"timeout is retried", context.DeadlineExceeded, policy},
+		{"invalid api key is not retried", exchangeratesapi.ErrInvalidAPIKey, RetryPolicy{}},
+		{"exhausted quota is not retried", exchangeratesapi.ErrMaxAllowedAPICalls, RetryPolicy{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &failedRepo{}
+			r := NewService(repo, WithRetryPolicy(policy))
+
+			require.NoError(t, r.ShiftFailed(context.Background(), "identifier", tt.err))
+			require.Equal(t, tt.expectedPolicy, repo.policy)
+		})
+	}
+}
+
+type cancelRepo struct {
+	RecordsRepo
+	exists bool
+}
+
+func (r *cancelRepo) Cancel(context.Context, string) error {
+	return ErrNotPending
+}
+
+func (r *cancelRepo) FetchByIdentifier(context.Context, string) (*Record, error) {
+	if !r.exists {
+		return nil, ErrNoRecord
+	}
+	return &Record{Status: StatusUpdated}, nil
+}
+
+func TestRecorder_Cancel(t *testing.T) {
+	r := NewService(&cancelRepo{exists: true})
+	require.ErrorIs(t, r.Cancel(context.Background(), "identifier"), ErrNotPending)
+
+	r = NewService(&cancelRepo{exists: false})
+	require.ErrorIs(t, r.Cancel(context.Background(), "identifier"), ErrNoRecord)
+}
+
+type idempotentRepo struct {
+	RecordsRepo
+	keys     map[string]IdempotencyKey
+	inserted int
+}
+
+func (r *idempotentRepo) InsertIdempotent(_ context.Context, _ *Record, key IdempotencyKey) (*IdempotencyKey, error) {
+	if stored, ok := r.keys[key.Key]; ok {
+		return &stored, nil
+	}
+	r.keys[key.Key] = key
+	r.inserted++
+	return nil, nil
+}
+
+func TestRecorder_RefreshIdempotent(t *testing.T) {
+	repo := &idempotentRepo{keys: make(map[string]IdempotencyKey)}
+	r := NewService(repo)
+	ctx := context.Background()
+
+	identifier, replayed, err := r.RefreshIdempotent(ctx, "key", "EUR", "USD")
+	require.NoError(t, err)
+	require.False(t, replayed)
+	<-r.Queue()
+
+	// Pairs are normalized before they are hashed
+	replay, replayed, err := r.RefreshIdempotent(ctx, "key", "eur", "usd")
+	require.NoError(t, err)
+	require.True(t, replayed)
+	require.Equal(t, identifier, replay)
+	require.Equal(t, 1, repo.inserted)
+	require.Zero(t, r.QueueLen())
+
+	_, _, err = r.RefreshIdempotent(ctx, "key", "EUR", "MXN")
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	_, _, err = r.RefreshIdempotent(ctx, " ", "EUR", "USD")
+	require.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+}
+
+type batchRepo struct {
+	RecordsRepo
+	inserted []*Record
+	latest   []Record
+}
+
+func (r *batchRepo) InsertBatch(_ context.Context, records []*Record) error {
+	r.inserted = records
+	return nil
+}
+
+func (r *batchRepo) FetchLatestBatch(context.Context, []Pair) ([]Record, error) {
+	return r.latest, nil
+}
+
+func TestRecorder_RefreshBatch(t *testing.T) {
+	repo := &batchRepo{}
+	r := NewService(repo)
+
+	items, err := r.RefreshBatch(context.Background(), []Pair{
+		{"eur", "usd"},
+		{"EUR", "JPY"},
+		{"EUR", "USD"},
+		{"EUR", "MXN"},
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	require.Len(t, repo.inserted, 2)
+	require.Equal(t, 2, r.QueueLen())
+
+	require.Equal(t, Pair{"EUR", "USD"}, items[0].Pair)
+	require.NoError(t, items[0].Err)
+	require.Equal(t, repo.inserted[0].Identifier, items[0].Identifier)
+	require.ErrorIs(t, items[1].Err, ErrNotSupportedSecondaryCurrency)
+	require.ErrorIs(t, items[2].Err, ErrDuplicatePair)
+	require.Empty(t, items[2].Identifier)
+	require.Equal(t, repo.inserted[1].Identifier, items[3].Identifier)
+
+	_, err = r.RefreshBatch(context.Background(), nil)
+	require.ErrorIs(t, err, ErrInvalidBatchSize)
+}
+
+func TestRecorder_FetchLatestBatch(t *testing.T) {
+	repo := &batchRepo{latest: []Record{{Base: "EUR", Secondary: "MXN", Rate: 20}}}
+	r := NewService(repo)
+
+	items, err := r.FetchLatestBatch(context.Background(), []Pair{{"EUR", "USD"}, {"EUR", "MXN"}, {"USD", "EUR"}})
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	require.ErrorIs(t, items[0].Err, ErrNoRecord)
+	require.NoError(t, items[1].Err)
+	require.Equal(t, 20.0, items[1].Record.Rate)
+	require.ErrorIs(t, items[2].Err, ErrNotSupportedBaseCurrency)
+}
+
+type exportRepo struct {
+	RecordsRepo
+	filter ExportFilter
+}
+
+func (r *exportRepo) Export(_ context.Context, filter ExportFilter, _ func(*Record) error) error {
+	r.filter = filter
+	return nil
+}
+
+func TestRecorder_Export(t *testing.T) {
+	repo := &exportRepo{}
+	r := NewService(repo)
+	ctx := context.Background()
+	fn := func(*Record) error { return nil }
+
+	require.NoError(t, r.Export(ctx, ExportFilter{Base: " eur", Secondary: "usd"}, fn))
+	require.Equal(t, ExportFilter{Base: "EUR", Secondary: "USD"}, repo.filter)
+
+	require.ErrorIs(t, r.Export(ctx, ExportFilter{Secondary: "JPY"}, fn), ErrNotSupportedSecondaryCurrency)
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.ErrorIs(t, r.Export(ctx, ExportFilter{From: day, To: day}, fn), ErrInvalidRange)
+}
+
+type pendingRepo struct {
+	RecordsRepo
+	pending []Record
+	limits  []int
+}
+
+func (r *pendingRepo) Insert(_ context.Context, record *Record) error {
+	r.pending = append(r.pending, *record)
+	return nil
+}
+
+func (r *pendingRepo) ClaimPending(_ context.Context, _ time.Time, limit int, _ time.Duration) ([]Record, error) {
+	r.limits = append(r.limits, limit)
+	n := min(limit, len(r.pending))
+	claimed := r.pending[:n]
+	r.pending = r.pending[n:]
+	return claimed, nil
+}
+
+func (r *pendingRepo) ClaimDueRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Record, error) {
+	return r.ClaimPending(ctx, now, limit, lease)
+}
+
+func TestRecorder_DispatchPending(t *testing.T) {
+	repo := &pendingRepo{}
+	r := NewService(repo, WithDurableQueue(time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		_, err := r.Refresh(ctx, "EUR", "USD")
+		require.NoError(t, err)
+	}
+	// Pending records wait in the database for a worker process
+	require.Zero(t, r.QueueLen())
+
+	n, err := r.DispatchPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	require.Equal(t, 5, r.QueueLen())
+
+	// A full queue claims nothing
+	n, err = r.DispatchPending(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	<-r.Queue()
+	n, err = r.DispatchPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int{5, 1}, repo.limits)
+}
+
+func TestRecorder_DispatchFullQueue(t *testing.T) {
+	repo := &pendingRepo{}
+	r := NewService(repo)
+	ctx := context.Background()
+
+	// Nothing consumes the queue, as while workers are paused
+	for i := 0; i < 7; i++ {
+		_, err := r.Refresh(ctx, "EUR", "USD")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, r.QueueLen())
+
+	// Records left out of the queue stay leased until the poller claims them
+	require.Len(t, repo.pending, 7)
+	for _, record := range repo.pending {
+		require.NotNil(t, record.Claimed_Until)
+	}
+}
+
+func TestRecorder_RescheduleDue(t *testing.T) {
+	repo := &pendingRepo{pending: make([]Record, 7)}
+	r := NewService(repo)
+	ctx := context.Background()
+
+	// Due retries are claimed only as far as the queue has room for them
+	n, err := r.RescheduleDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	n, err = r.RescheduleDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.Equal(t, []int{5}, repo.limits)
+	require.Len(t, repo.pending, 2)
+
+	// Records queued in memory are leased, so that they are claimed again if
+	// the process stops before processing them
+	<-r.Queue()
+	_, err = r.Refresh(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.NotNil(t, repo.pending[len(repo.pending)-1].Claimed_Until)
+}
+
+func TestRecorder_CreatedBy(t *testing.T) {
+	repo := &pendingRepo{}
+	r := NewService(repo, WithDurableQueue(time.Minute))
+
+	_, err := r.Refresh(WithCreator(context.Background(), "dashboard"), "EUR", "USD")
+	require.NoError(t, err)
+	_, err = r.Refresh(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+
+	require.Equal(t, "dashboard", repo.pending[0].CreatedBy)
+	require.Empty(t, repo.pending[1].CreatedBy)
+}
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// writeOutbox stores the record event in the outbox table within the caller's
+// transaction, so the event is persisted if and only if the state change is.
+func writeOutbox(ctx context.Context, tx pgx.Tx, eventType string, record *exchangerates.Record) error {
+	payload, err := json.Marshal(exchangerates.NewRecordEvent(record))
+	if err != nil {
+		return fmt.Errorf("marshalling outbox payload error: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO outbox (aggregate_id, event_type, payload, created_at) VALUES ($1,$2,$3,$4)",
+		record.Identifier, eventType, payload, record.Updated_At)
+
+	return err
+}
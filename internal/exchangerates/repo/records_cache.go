@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/cache"
+)
+
+// Defaults of the cache options, see CacheSize, CacheTTL and
+// CacheNegativeTTL.
+const (
+	DefaultCacheSize        = 100
+	DefaultCacheTTL         = time.Minute
+	DefaultCacheNegativeTTL = 10 * time.Second
+)
+
+// cachedRecordsRepository serves FetchLatest from memory and passes every
+// other call through to the wrapped repository.
+type cachedRecordsRepository struct {
+	exchangerates.RecordsRepo
+
+	latest      *cache.LRU[exchangerates.Pair, *exchangerates.Record]
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// generation changes on every invalidation, so lookups racing with an
+	// update do not store the rate they read before it.
+	generation atomic.Uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+type CacheOption func(*cachedRecordsRepository)
+
+// CacheSize bounds the number of pairs kept.
+func CacheSize(size int) CacheOption {
+	return func(r *cachedRecordsRepository) {
+		r.size = size
+	}
+}
+
+// CacheTTL is how long latest records are kept at most, in case another
+// instance updated the pair.
+func CacheTTL(ttl time.Duration) CacheOption {
+	return func(r *cachedRecordsRepository) {
+		r.ttl = ttl
+	}
+}
+
+// CacheNegativeTTL is how long pairs without an updated record are
+// remembered.
+func CacheNegativeTTL(ttl time.Duration) CacheOption {
+	return func(r *cachedRecordsRepository) {
+		r.negativeTTL = ttl
+	}
+}
+
+func NewCachedRecordsRepository(next exchangerates.RecordsRepo, opts ...CacheOption) (*cachedRecordsRepository, error) {
+
+	if next == nil {
+		return nil, errors.New("provided records repository is nil")
+	}
+
+	r := &cachedRecordsRepository{
+		RecordsRepo: next,
+		size:        DefaultCacheSize,
+		ttl:         DefaultCacheTTL,
+		negativeTTL: DefaultCacheNegativeTTL,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.latest = cache.New[exchangerates.Pair, *exchangerates.Record](r.size, r.ttl)
+
+	return r, nil
+}
+
+// FetchLatest returns the cached record of the pair, or ErrNoRecord when the
+// pair is known to have none.
+func (r *cachedRecordsRepository) FetchLatest(ctx context.Context, base string, secondary string) (*exchangerates.Record, error) {
+	pair := exchangerates.Pair{Base: base, Secondary: secondary}
+
+	if record, ok := r.latest.Get(pair); ok {
+		r.hits.Add(1)
+		if record == nil {
+			return nil, exchangerates.ErrNoRecord
+		}
+
+		copied := *record
+		return &copied, nil
+	}
+	r.misses.Add(1)
+
+	generation := r.generation.Load()
+
+	record, err := r.RecordsRepo.FetchLatest(ctx, base, secondary)
+	if err != nil && !errors.Is(err, exchangerates.ErrNoRecord) {
+		return nil, err
+	}
+
+	if r.generation.Load() == generation {
+		if err != nil {
+			r.latest.SetWithTTL(pair, nil, r.negativeTTL)
+		} else {
+			copied := *record
+			r.latest.Set(pair, &copied)
+		}
+	}
+
+	return record, err
+}
+
+func (r *cachedRecordsRepository) ShiftUpdated(ctx context.Context, identifier string, rate float64) error {
+	if err := r.RecordsRepo.ShiftUpdated(ctx, identifier, rate); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, identifier)
+	return nil
+}
+
+func (r *cachedRecordsRepository) AcceptQuarantined(ctx context.Context, identifier string) error {
+	if err := r.RecordsRepo.AcceptQuarantined(ctx, identifier); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, identifier)
+	return nil
+}
+
+// Import drops every pair, since imported rates may be newer than cached ones.
+func (r *cachedRecordsRepository) Import(ctx context.Context, records []*exchangerates.Record) ([]bool, error) {
+	inserted, err := r.RecordsRepo.Import(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	r.generation.Add(1)
+	r.latest.Purge()
+	return inserted, nil
+}
+
+// Stats returns the hits and misses of FetchLatest since start.
+func (r *cachedRecordsRepository) Stats() exchangerates.CacheStats {
+	return exchangerates.CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Size:   r.latest.Len(),
+	}
+}
+
+// invalidate drops the pair of the record, which became its latest record.
+func (r *cachedRecordsRepository) invalidate(ctx context.Context, identifier string) {
+	r.generation.Add(1)
+
+	record, err := r.RecordsRepo.FetchByIdentifier(ctx, identifier)
+	if err != nil {
+		// NoReturnErr: the pair is unknown, so every pair is dropped
+		r.latest.Purge()
+		return
+	}
+
+	r.latest.Delete(exchangerates.Pair{Base: record.Base, Secondary: record.Secondary})
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/stretchr/testify/require"
+)
+
+type latestRepo struct {
+	exchangerates.RecordsRepo
+	latest  map[exchangerates.Pair]exchangerates.Record
+	queries int
+}
+
+func (r *latestRepo) FetchLatest(_ context.Context, base string, secondary string) (*exchangerates.Record, error) {
+	r.queries++
+	record, ok := r.latest[exchangerates.Pair{Base: base, Secondary: secondary}]
+	if !ok {
+		return nil, exchangerates.ErrNoRecord
+	}
+	return &record, nil
+}
+
+func (r *latestRepo) ShiftUpdated(_ context.Context, identifier string, rate float64) error {
+	r.latest[exchangerates.Pair{Base: "EUR", Secondary: "USD"}] = exchangerates.Record{Identifier: identifier, Base: "EUR", Secondary: "USD", Rate: rate}
+	return nil
+}
+
+func (r *latestRepo) FetchByIdentifier(_ context.Context, identifier string) (*exchangerates.Record, error) {
+	if identifier == "unknown" {
+		return nil, errors.New("connection refused")
+	}
+	return &exchangerates.Record{Identifier: identifier, Base: "EUR", Secondary: "USD"}, nil
+}
+
+func TestCachedRecordsRepository(t *testing.T) {
+	ctx := context.Background()
+	next := &latestRepo{latest: map[exchangerates.Pair]exchangerates.Record{
+		{Base: "EUR", Secondary: "MXN"}: {Identifier: "mxn", Rate: 20},
+	}}
+	r, err := NewCachedRecordsRepository(next)
+	require.NoError(t, err)
+
+	record, err := r.FetchLatest(ctx, "EUR", "MXN")
+	require.NoError(t, err)
+	require.Equal(t, 20.0, record.Rate)
+
+	// Callers cannot change the cached record
+	record.Rate = 0
+	record, err = r.FetchLatest(ctx, "EUR", "MXN")
+	require.NoError(t, err)
+	require.Equal(t, 20.0, record.Rate)
+	require.Equal(t, 1, next.queries)
+
+	// Pairs without a rate are cached too
+	_, err = r.FetchLatest(ctx, "EUR", "USD")
+	require.ErrorIs(t, err, exchangerates.ErrNoRecord)
+	_, err = r.FetchLatest(ctx, "EUR", "USD")
+	require.ErrorIs(t, err, exchangerates.ErrNoRecord)
+	require.Equal(t, 2, next.queries)
+
+	// Updates drop their pair only
+	require.NoError(t, r.ShiftUpdated(ctx, "usd", 1.1))
+	record, err = r.FetchLatest(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, 1.1, record.Rate)
+	_, err = r.FetchLatest(ctx, "EUR", "MXN")
+	require.NoError(t, err)
+	require.Equal(t, 3, next.queries)
+
+	// Updates of records that cannot be read back drop every pair
+	require.NoError(t, r.ShiftUpdated(ctx, "unknown", 1.2))
+	_, err = r.FetchLatest(ctx, "EUR", "MXN")
+	require.NoError(t, err)
+	require.Equal(t, 4, next.queries)
+
+	require.Equal(t, exchangerates.CacheStats{Hits: 3, Misses: 4, Size: 1}, r.Stats())
+}
@@ -3,6 +3,8 @@ package repo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
@@ -25,25 +27,71 @@ func NewRecordsRepository(connPool *pgxpool.Pool) (*recordsRepository, error) {
 }
 
 func (r *recordsRepository) Insert(ctx context.Context, record *exchangerates.Record) error {
+	return pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		return insertRecord(ctx, tx, record)
+	})
+}
+
+func (r *recordsRepository) InsertIdempotent(ctx context.Context, record *exchangerates.Record, key exchangerates.IdempotencyKey) (*exchangerates.IdempotencyKey, error) {
+	var stored *exchangerates.IdempotencyKey
+
+	err := pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM idempotency_keys WHERE client = $1 AND expires_at <= $2", key.Client, time.Now()); err != nil {
+			return err
+		}
+
+		// A concurrent request with the same key blocks here until the first
+		// one commits, and then reads its key below.
+		tag, err := tx.Exec(ctx, "INSERT INTO idempotency_keys (client, key, request_hash, identifier, expires_at) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (client, key) DO NOTHING",
+			key.Client, key.Key, key.RequestHash, key.Identifier, key.Expires_At)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			stored = &exchangerates.IdempotencyKey{Client: key.Client, Key: key.Key}
+			return tx.QueryRow(ctx, "SELECT request_hash, identifier, expires_at FROM idempotency_keys WHERE client = $1 AND key = $2", key.Client, key.Key).
+				Scan(&stored.RequestHash, &stored.Identifier, &stored.Expires_At)
+		}
+
+		return insertRecord(ctx, tx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+func (r *recordsRepository) InsertBatch(ctx context.Context, records []*exchangerates.Record) error {
+	return pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		for _, record := range records {
+			if err := insertRecord(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func insertRecord(ctx context.Context, tx pgx.Tx, record *exchangerates.Record) error {
 	current_time := time.Now()
 	rate := 0
 
-	_, err := r.connPool.Exec(ctx, "INSERT INTO records (identifier, base, secondary, rate, status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7)",
-		record.Identifier, record.Base, record.Secondary, rate, record.Status, current_time, current_time)
-
+	err := tx.QueryRow(ctx, "INSERT INTO records (identifier, base, secondary, rate, status, created_at, updated_at, trace_parent, request_id, created_by, claimed_until) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING id",
+		record.Identifier, record.Base, record.Secondary, rate, record.Status, current_time, current_time, record.TraceParent, record.RequestID, record.CreatedBy, record.Claimed_Until).Scan(&record.Id)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-func (r *recordsRepository) FetchByIdentifier(ctx context.Context, identifier string) (*exchangerates.Record, error) {
-	record := exchangerates.Record{}
+	record.Created_At = current_time
+	record.Updated_At = current_time
 
-	err := r.connPool.QueryRow(ctx, "SELECT * FROM records WHERE identifier = $1 LIMIT 1", identifier).Scan(
-		&record.Id, &record.Identifier, &record.Base, &record.Secondary, &record.Rate,
-		&record.Status, &record.Created_At, &record.Updated_At)
+	return writeOutbox(ctx, tx, exchangerates.EventRecordCreated, record)
+}
 
+func (r *recordsRepository) FetchByIdentifier(ctx context.Context, identifier string) (*exchangerates.Record, error) {
+	record, err := scanRecord(r.connPool.QueryRow(ctx, "SELECT * FROM records WHERE identifier = $1 LIMIT 1", identifier))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, exchangerates.ErrNoRecord
@@ -55,13 +103,8 @@ func (r *recordsRepository) FetchByIdentifier(ctx context.Context, identifier st
 }
 
 func (r *recordsRepository) FetchLatest(ctx context.Context, base string, secondary string) (*exchangerates.Record, error) {
-	record := exchangerates.Record{}
-
-	err := r.connPool.QueryRow(ctx, "SELECT * FROM records WHERE base = $1 AND secondary = $2 AND STATUS = $3 ORDER BY ID DESC LIMIT 1",
-		base, secondary, exchangerates.StatusUpdated).Scan(
-		&record.Id, &record.Identifier, &record.Base, &record.Secondary, &record.Rate,
-		&record.Status, &record.Created_At, &record.Updated_At)
-
+	record, err := scanRecord(r.connPool.QueryRow(ctx, "SELECT * FROM records WHERE base = $1 AND secondary = $2 AND STATUS = $3 ORDER BY updated_at DESC, id DESC LIMIT 1",
+		base, secondary, exchangerates.StatusUpdated))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, exchangerates.ErrNoRecord
@@ -72,18 +115,396 @@ func (r *recordsRepository) FetchLatest(ctx context.Context, base string, second
 	return &record, nil
 }
 
+func (r *recordsRepository) FetchLatestBatch(ctx context.Context, pairs []exchangerates.Pair) ([]exchangerates.Record, error) {
+	bases := make([]string, len(pairs))
+	secondaries := make([]string, len(pairs))
+	for i, pair := range pairs {
+		bases[i] = pair.Base
+		secondaries[i] = pair.Secondary
+	}
+
+	rows, err := r.connPool.Query(ctx,
+		`SELECT DISTINCT ON (base, secondary) * FROM records
+		WHERE (base, secondary) IN (SELECT * FROM UNNEST($1::text[], $2::text[])) AND status = $3
+		ORDER BY base, secondary, updated_at DESC, id DESC`,
+		bases, secondaries, exchangerates.StatusUpdated)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+		return scanRecord(row)
+	})
+}
+
+// _exportPageSize is how many records an export reads at once.
+const _exportPageSize = 500
+
+func (r *recordsRepository) Export(ctx context.Context, filter exchangerates.ExportFilter, fn func(*exchangerates.Record) error) error {
+	var (
+		conds []string
+		args  []any
+	)
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Base != "" {
+		where("base = $%d", filter.Base)
+	}
+	if filter.Secondary != "" {
+		where("secondary = $%d", filter.Secondary)
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]int, len(filter.Statuses))
+		for i, s := range filter.Statuses {
+			statuses[i] = int(s)
+		}
+		where("status = ANY($%d::smallint[])", statuses)
+	}
+	if !filter.From.IsZero() {
+		where("updated_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("updated_at < $%d", filter.To)
+	}
+
+	// Pages are read after the last record of the previous one, and the
+	// connection is released while a page is written, so that a slow client
+	// does not hold a connection for the whole export.
+	var last *exchangerates.Record
+	for {
+		pageConds, pageArgs := conds, args
+		if last != nil {
+			pageArgs = append(pageArgs[:len(pageArgs):len(pageArgs)], last.Updated_At, last.Id)
+			pageConds = append(pageConds[:len(pageConds):len(pageConds)], fmt.Sprintf("(updated_at, id) > ($%d, $%d)", len(pageArgs)-1, len(pageArgs)))
+		}
+
+		query := "SELECT * FROM records"
+		if len(pageConds) > 0 {
+			query += " WHERE " + strings.Join(pageConds, " AND ")
+		}
+		query += fmt.Sprintf(" ORDER BY updated_at, id LIMIT %d", _exportPageSize)
+
+		rows, err := r.connPool.Query(ctx, query, pageArgs...)
+		if err != nil {
+			return err
+		}
+
+		page, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+			return scanRecord(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range page {
+			if err := fn(&page[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < _exportPageSize {
+			return nil
+		}
+		last = &page[len(page)-1]
+	}
+}
+
+var _importColumns = []string{"identifier", "base", "secondary", "rate", "status", "created_at", "updated_at", "created_by", "source"}
+
+// Import copies the records to a temporary table, then inserts them along
+// the unique index of updated rates, which skips the duplicates of rates
+// stored by any writer.
+func (r *recordsRepository) Import(ctx context.Context, records []*exchangerates.Record) ([]bool, error) {
+	copied := make([][]any, len(records))
+	for i, record := range records {
+		copied[i] = []any{
+			record.Identifier, record.Base, record.Secondary, record.Rate, int16(record.Status),
+			record.Created_At, record.Updated_At, record.CreatedBy, record.Source,
+		}
+	}
+
+	stored := make(map[string]bool, len(records))
+	err := pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		// The temporary table copies only the imported columns, so that it
+		// neither draws ids from the records sequence nor requires them
+		if _, err := tx.Exec(ctx, `CREATE TEMPORARY TABLE records_import ON COMMIT DROP AS
+			SELECT identifier, base, secondary, rate, status, created_at, updated_at, created_by, source
+			FROM records WITH NO DATA`); err != nil {
+			return err
+		}
+
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"records_import"}, _importColumns, pgx.CopyFromRows(copied)); err != nil {
+			return err
+		}
+
+		// The status is a literal, since Postgres infers the partial unique
+		// index as the arbiter only from a predicate it can prove, and a
+		// parameter of a generic plan proves nothing. 2 is StatusUpdated.
+		rows, err := tx.Query(ctx, `INSERT INTO records
+			(identifier, base, secondary, rate, status, created_at, updated_at, created_by, source)
+			SELECT identifier, base, secondary, rate, status, created_at, updated_at, created_by, source
+			FROM records_import
+			ON CONFLICT (base, secondary, updated_at) WHERE status = 2 DO NOTHING
+			RETURNING identifier`)
+		if err != nil {
+			return err
+		}
+
+		var identifier string
+		_, err = pgx.ForEachRow(rows, []any{&identifier}, func() error {
+			stored[identifier] = true
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inserted := make([]bool, len(records))
+	for i, record := range records {
+		inserted[i] = stored[record.Identifier]
+	}
+
+	return inserted, nil
+}
+
+func (r *recordsRepository) MissingDays(ctx context.Context, pair exchangerates.Pair, from time.Time, to time.Time) ([]time.Time, error) {
+	rows, err := r.connPool.Query(ctx,
+		`SELECT day::date FROM generate_series($3::date, $4::date, INTERVAL '1 day') AS day
+		WHERE NOT EXISTS (
+			SELECT 1 FROM records WHERE base = $1 AND secondary = $2 AND status = $5
+			AND updated_at >= day AND updated_at < day + INTERVAL '1 day'
+		)
+		ORDER BY day`,
+		pair.Base, pair.Secondary, from, to, exchangerates.StatusUpdated)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[time.Time])
+}
+
+func (r *recordsRepository) FetchHistory(ctx context.Context, base string, secondary string, since time.Time) ([]exchangerates.Record, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT * FROM records WHERE base = $1 AND secondary = $2 AND status = $3 AND updated_at >= $4 ORDER BY updated_at",
+		base, secondary, exchangerates.StatusUpdated, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+		return scanRecord(row)
+	})
+}
+
+func (r *recordsRepository) FetchByStatus(ctx context.Context, status exchangerates.Status) ([]exchangerates.Record, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT * FROM records WHERE status = $1 ORDER BY id", status)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+		return scanRecord(row)
+	})
+}
+
 func (r *recordsRepository) ShiftUpdated(ctx context.Context, identifier string, rate float64) error {
-	current_time := time.Now()
-	_, err := r.connPool.Exec(ctx, "UPDATE records SET rate = $1, status = $2, updated_at = $3 where identifier = $4",
-		rate, exchangerates.StatusUpdated, current_time, identifier)
+	return r.shift(ctx, exchangerates.EventRecordUpdated, exchangerates.ErrNotPending,
+		"UPDATE records SET rate = $1, status = $2, updated_at = $3 where identifier = $4 AND status = $5 RETURNING *",
+		rate, exchangerates.StatusUpdated, time.Now(), identifier, exchangerates.StatusCreated)
+}
 
-	return err
+func (r *recordsRepository) ShiftFailed(ctx context.Context, identifier string, failure exchangerates.Failure, policy exchangerates.RetryPolicy) error {
+	// Right-hand side column references see the row before the update, so
+	// attempts + 1 is the number of attempts including the failed one.
+	return r.shift(ctx, exchangerates.EventRecordFailed, exchangerates.ErrNotPending,
+		`UPDATE records SET
+			attempts = attempts + 1,
+			status = CASE WHEN $1 > 0 AND attempts + 1 >= $1 THEN $2::smallint ELSE $3::smallint END,
+			next_attempt_at = CASE WHEN attempts + 1 < $1
+				THEN $4::timestamp + LEAST($5 * POWER(2, attempts), $6) * INTERVAL '1 second'
+				ELSE NULL END,
+			failure_code = $7, failure_message = $8, updated_at = $4
+		WHERE identifier = $9 AND status = $10 RETURNING *`,
+		policy.MaxAttempts, exchangerates.StatusExhausted, exchangerates.StatusFailed,
+		time.Now(), policy.BaseDelay.Seconds(), policy.MaxDelay.Seconds(),
+		failure.Code, failure.Message, identifier, exchangerates.StatusCreated)
 }
 
-func (r *recordsRepository) ShiftFailed(ctx context.Context, identifier string) error {
-	current_time := time.Now()
-	_, err := r.connPool.Exec(ctx, "UPDATE records SET status = $1, updated_at = $2 where identifier = $3",
-		exchangerates.StatusFailed, current_time, identifier)
+func (r *recordsRepository) ShiftQuarantined(ctx context.Context, identifier string, rate float64) error {
+	return r.shift(ctx, exchangerates.EventRecordQuarantined, exchangerates.ErrNotPending,
+		"UPDATE records SET rate = $1, status = $2, updated_at = $3 where identifier = $4 AND status = $5 RETURNING *",
+		rate, exchangerates.StatusQuarantined, time.Now(), identifier, exchangerates.StatusCreated)
+}
+
+func (r *recordsRepository) AcceptQuarantined(ctx context.Context, identifier string) error {
+	return r.shift(ctx, exchangerates.EventRecordUpdated, exchangerates.ErrNotQuarantined,
+		"UPDATE records SET status = $1, updated_at = $2 where identifier = $3 AND status = $4 RETURNING *",
+		exchangerates.StatusUpdated, time.Now(), identifier, exchangerates.StatusQuarantined)
+}
+
+func (r *recordsRepository) RejectQuarantined(ctx context.Context, identifier string, failure exchangerates.Failure) error {
+	return r.shift(ctx, exchangerates.EventRecordFailed, exchangerates.ErrNotQuarantined,
+		"UPDATE records SET status = $1, failure_code = $2, failure_message = $3, updated_at = $4 where identifier = $5 AND status = $6 RETURNING *",
+		exchangerates.StatusFailed, failure.Code, failure.Message, time.Now(), identifier, exchangerates.StatusQuarantined)
+}
+
+func (r *recordsRepository) ClaimDueRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]exchangerates.Record, error) {
+	var records []exchangerates.Record
+
+	err := pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `UPDATE records SET status = $1, next_attempt_at = NULL, updated_at = $2, claimed_until = $5
+			WHERE id IN (
+				SELECT id FROM records WHERE status = $3 AND next_attempt_at <= $2
+				ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED
+			) RETURNING *`,
+			exchangerates.StatusCreated, now, exchangerates.StatusFailed, limit, now.Add(lease))
+		if err != nil {
+			return err
+		}
+
+		records, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+			return scanRecord(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			if err := writeOutbox(ctx, tx, exchangerates.EventRecordRetrying, &records[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// ClaimPending leaves the status as it is, so that the claim of a worker
+// that died expires by itself.
+func (r *recordsRepository) ClaimPending(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]exchangerates.Record, error) {
+	rows, err := r.connPool.Query(ctx, `UPDATE records SET claimed_until = $1
+		WHERE id IN (
+			SELECT id FROM records WHERE status = $2 AND (claimed_until IS NULL OR claimed_until < $3)
+			ORDER BY id LIMIT $4 FOR UPDATE SKIP LOCKED
+		) RETURNING *`,
+		now.Add(lease), exchangerates.StatusCreated, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.Record, error) {
+		return scanRecord(row)
+	})
+}
+
+func (r *recordsRepository) ClaimRetry(ctx context.Context, identifier string, lease time.Duration) (*exchangerates.Record, error) {
+	var record exchangerates.Record
+
+	now := time.Now()
+	var claimedUntil *time.Time
+	if lease > 0 {
+		until := now.Add(lease)
+		claimedUntil = &until
+	}
+
+	err := pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		var err error
+		record, err = scanRecord(tx.QueryRow(ctx, "UPDATE records SET status = $1, next_attempt_at = NULL, claimed_until = $6, updated_at = $2 WHERE identifier = $3 AND status IN ($4, $5) RETURNING *",
+			exchangerates.StatusCreated, now, identifier, exchangerates.StatusFailed, exchangerates.StatusExhausted, claimedUntil))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return exchangerates.ErrNotRetryable
+			}
+			return err
+		}
+
+		return writeOutbox(ctx, tx, exchangerates.EventRecordRetrying, &record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *recordsRepository) Cancel(ctx context.Context, identifier string) error {
+	return r.shift(ctx, exchangerates.EventRecordCancelled, exchangerates.ErrNotPending,
+		`UPDATE records SET status = $1, next_attempt_at = NULL, updated_at = $2
+		WHERE identifier = $3 AND (status = $4 OR (status = $5 AND next_attempt_at IS NOT NULL)) RETURNING *`,
+		exchangerates.StatusCancelled, time.Now(), identifier, exchangerates.StatusCreated, exchangerates.StatusFailed)
+}
+
+func (r *recordsRepository) CountByStatus(ctx context.Context) (map[exchangerates.Status]int, error) {
+	rows, err := r.connPool.Query(ctx, "SELECT status, COUNT(*) FROM records GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[exchangerates.Status]int)
+	for rows.Next() {
+		var (
+			status exchangerates.Status
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *recordsRepository) FailureCounts(ctx context.Context, since time.Time) ([]exchangerates.FailureCount, error) {
+	rows, err := r.connPool.Query(ctx, `SELECT failure_code, COUNT(*),
+			(ARRAY_AGG(failure_message ORDER BY updated_at DESC))[1], MAX(updated_at)
+		FROM records WHERE status IN ($1, $2) AND failure_code <> '' AND updated_at >= $3
+		GROUP BY failure_code ORDER BY COUNT(*) DESC`,
+		exchangerates.StatusFailed, exchangerates.StatusExhausted, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (exchangerates.FailureCount, error) {
+		var fc exchangerates.FailureCount
+		err := row.Scan(&fc.Code, &fc.Count, &fc.LastMessage, &fc.Last_At)
+		return fc, err
+	})
+}
+
+// shift runs a status changing query, which must return the whole row, and
+// writes the resulting event to the outbox in the same transaction. noRowsErr
+// is returned when the query matched no record.
+func (r *recordsRepository) shift(ctx context.Context, eventType string, noRowsErr error, query string, args ...any) error {
+	return pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		record, err := scanRecord(tx.QueryRow(ctx, query, args...))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return noRowsErr
+			}
+			return err
+		}
+
+		return writeOutbox(ctx, tx, eventType, &record)
+	})
+}
+
+func scanRecord(row pgx.Row) (exchangerates.Record, error) {
+	record := exchangerates.Record{}
+
+	err := row.Scan(
+		&record.Id, &record.Identifier, &record.Base, &record.Secondary, &record.Rate,
+		&record.Status, &record.Created_At, &record.Updated_At,
+		&record.Failure.Code, &record.Failure.Message,
+		&record.Attempts, &record.NextAttempt_At,
+		&record.TraceParent, &record.RequestID, &record.CreatedBy, &record.Source,
+		&record.Claimed_Until)
 
-	return err
+	return record, err
 }
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/migrations"
+	"github.com/ZakirAvrora/exchange-rate/pkg/postgres"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/require"
+	// migrate tools
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+)
+
+// newTestRecordsRepository migrates the database of PG_TEST_URL and returns
+// a repository on a single connection, or skips the test without one.
+func newTestRecordsRepository(t *testing.T) *recordsRepository {
+	t.Helper()
+
+	url := os.Getenv("PG_TEST_URL")
+	if url == "" {
+		t.Skip("PG_TEST_URL is not set")
+	}
+
+	src, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	m, err := migrate.NewWithSourceInstance("iofs", src, url)
+	require.NoError(t, err)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(t, err)
+	}
+
+	pool, err := postgres.New(url, postgres.MaxPoolSize(1))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	r, err := NewRecordsRepository(pool)
+	require.NoError(t, err)
+	return r
+}
+
+func TestRecordsRepository_Import(t *testing.T) {
+	r := newTestRecordsRepository(t)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("import-test-%d-", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, err := r.connPool.Exec(ctx, "DELETE FROM records WHERE identifier LIKE $1", prefix+"%")
+		require.NoError(t, err)
+	})
+
+	updated := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	newRecord := func(i int) *exchangerates.Record {
+		return &exchangerates.Record{
+			Identifier: fmt.Sprintf("%s%d", prefix, i),
+			Base:       "EUR",
+			Secondary:  "USD",
+			Rate:       1 + float64(i)/100,
+			Status:     exchangerates.StatusUpdated,
+			Created_At: updated,
+			Updated_At: updated.Add(time.Duration(i) * time.Microsecond),
+			Source:     exchangerates.SourceImported,
+		}
+	}
+
+	// Postgres switches a statement to a generic plan after five executions
+	// on a connection, so the duplicates must be skipped beyond them too
+	for i := 1; i <= 8; i++ {
+		duplicate := newRecord(i - 1)
+		duplicate.Identifier += "-duplicate"
+
+		inserted, err := r.Import(ctx, []*exchangerates.Record{newRecord(i), duplicate})
+		require.NoError(t, err, "import %d", i)
+		require.Equal(t, []bool{true, i == 1}, inserted, "import %d", i)
+	}
+}
@@ -0,0 +1,27 @@
+package exchangerates
+
+import "time"
+
+// Defaults of the retry policy, see DefaultRetryPolicy.
+const (
+	DefaultRetryMaxAttempts = 5
+	DefaultRetryBaseDelay   = 30 * time.Second
+	DefaultRetryMaxDelay    = 30 * time.Minute
+)
+
+// RetryPolicy is the attempt budget of a record. Failed attempts are retried
+// with exponential backoff starting at BaseDelay and capped at MaxDelay.
+// A zero MaxAttempts disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+	}
+}
@@ -2,6 +2,7 @@ package exchangerates
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -14,45 +15,156 @@ type Record struct {
 	Rate       float64
 	Created_At time.Time
 	Updated_At time.Time
+	// Failure is set when Status is StatusFailed or StatusExhausted.
+	Failure Failure
+	// Attempts counts failed refresh attempts. NextAttempt_At is set while a
+	// failed record waits to be retried.
+	Attempts       int
+	NextAttempt_At *time.Time
+	// TraceParent is the W3C traceparent of the request that created the
+	// record, linked from the span that processes it.
+	TraceParent string
+	// RequestID is the id of the HTTP request that created the record.
+	RequestID string
+	// CreatedBy is the API client that requested the refresh.
+	CreatedBy string
+	// Source is SourceProvider, or SourceImported and SourceBackfilled for
+	// historical rates.
+	Source string
+	// Claimed_Until is set while a worker processes the pending record.
+	Claimed_Until *time.Time
 }
 
+// Sources of the rate of records.
+const (
+	SourceProvider   = "provider"
+	SourceImported   = "imported"
+	SourceBackfilled = "backfilled"
+)
+
 type Status int
 
 var (
-	StatusUnknown  Status = 0
-	StatusCreated  Status = 1
-	StatusUpdated  Status = 2
-	StatusFailed   Status = 3
-	StatusSentinel Status = 4
+	StatusUnknown     Status = 0
+	StatusCreated     Status = 1
+	StatusUpdated     Status = 2
+	StatusFailed      Status = 3
+	StatusQuarantined Status = 4
+	StatusExhausted   Status = 5
+	StatusCancelled   Status = 6
+	StatusSentinel    Status = 7
 )
 
+var statusNames = map[Status]string{
+	StatusUnknown:     "unknown",
+	StatusCreated:     "created",
+	StatusUpdated:     "updated",
+	StatusFailed:      "failed",
+	StatusQuarantined: "quarantined",
+	StatusExhausted:   "exhausted",
+	StatusCancelled:   "cancelled",
+}
+
+// Final reports whether records in the status never change again.
+func (s Status) Final() bool {
+	return s == StatusUpdated || s == StatusCancelled
+}
+
+// ParseStatus returns the status of the name, or ErrInvalidStatus.
+func ParseStatus(name string) (Status, error) {
+	for s, n := range statusNames {
+		if n == name && s != StatusUnknown {
+			return s, nil
+		}
+	}
+	return StatusUnknown, fmt.Errorf("%w: %q", ErrInvalidStatus, name)
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return statusNames[StatusUnknown]
+}
+
 var (
 	ErrNoRecord                      = errors.New("no record was found")
 	ErrNotSupportedBaseCurrency      = errors.New("invalid base currency code")
 	ErrNotSupportedSecondaryCurrency = errors.New("invalid secondary currency code")
+	ErrImplausibleRate               = errors.New("implausible rate")
+	ErrNotQuarantined                = errors.New("record is not quarantined")
+	ErrNotRetryable                  = errors.New("record is not failed")
+	ErrNotPending                    = errors.New("record is not pending")
+	ErrInvalidWorkerCount            = errors.New("invalid worker count")
+	ErrInvalidIdempotencyKey         = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused          = errors.New("idempotency key was used for a different request")
+	ErrInvalidBatchSize              = errors.New("invalid batch size")
+	ErrDuplicatePair                 = errors.New("pair is requested more than once")
+	ErrInvalidStatus                 = errors.New("invalid status")
+	ErrInvalidRange                  = errors.New("invalid date range")
+	ErrInvalidImport                 = errors.New("invalid import")
+	ErrInvalidRate                   = errors.New("invalid rate")
+	ErrInvalidTime                   = errors.New("invalid time")
+	ErrDuplicateRate                 = errors.New("rate of the pair at this time already exists")
+	ErrInvalidCurrencies             = errors.New("invalid supported currencies")
 )
 
-func validBaseCurrency(code string) bool {
-	return supportedBaseCurrency[code]
+// ExportFilter selects the records to export, zero fields select every
+// record. Records are selected by the time they were last updated, from
+// From inclusive to To exclusive.
+type ExportFilter struct {
+	Base      string
+	Secondary string
+	Statuses  []Status
+	From      time.Time
+	To        time.Time
 }
 
-func validSecondaryCurrency(code string) bool {
-	return supportedSecondaryCurrency[code]
+type Pair struct {
+	Base      string
+	Secondary string
 }
 
-var supportedBaseCurrency = map[string]bool{
-	"EUR": true,
+// BatchItem is the outcome of a pair of a batch request. Err is set when the
+// pair was invalid or, for reads, has no rate yet.
+type BatchItem struct {
+	Pair       Pair
+	Identifier string
+	Record     *Record
+	Err        error
+}
+
+// IdempotencyKey remembers the record created by a refresh request, so
+// retries of the request return it instead of creating another one. Keys
+// are scoped to the API client.
+type IdempotencyKey struct {
+	Client      string
+	Key         string
+	RequestHash string
+	Identifier  string
+	Expires_At  time.Time
+}
+
+// FailureCount groups failed records by failure code.
+type FailureCount struct {
+	Code        FailureCode
+	Count       int
+	LastMessage string
+	Last_At     time.Time
 }
 
-var supportedSecondaryCurrency = map[string]bool{
-	"BTC": true,
-	"MXN": true,
-	"USD": true,
-	"BYR": true,
-	"AED": true,
-	"KZT": true,
-	"RUB": true,
-	"XAU": true,
-	"XAG": true,
-	"LYD": true,
+// CacheStats counts the lookups of a cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// InFlight is a record being processed by a worker.
+type InFlight struct {
+	Worker     int
+	Identifier string
+	Base       string
+	Secondary  string
+	Started_At time.Time
 }
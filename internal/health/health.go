@@ -0,0 +1,190 @@
+// Package health reports whether the service is alive and ready to serve
+// traffic by running named dependency checks.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	_shutdownCheck = "shutdown"
+)
+
+// DefaultTimeout is the default of Timeout.
+const DefaultTimeout = 2 * time.Second
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Check returns an error when the dependency it checks is unhealthy.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Status    string  `json:"status"               example:"ok"`
+	LatencyMs float64 `json:"latency_ms"           example:"1.25"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"  example:"ok"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	mu       sync.RWMutex
+	live     []namedCheck
+	ready    []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+type Option func(*Checker)
+
+// Timeout bounds the duration of every single check.
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
+
+func New(opts ...Option) *Checker {
+	c := &Checker{
+		timeout: DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// AddLivenessCheck registers a check of the process itself. Liveness checks
+// are part of the readiness report as well.
+func (c *Checker) AddLivenessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.live = append(c.live, namedCheck{name, check})
+}
+
+// AddReadinessCheck registers a check of a dependency required to serve
+// traffic.
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ready = append(c.ready, namedCheck{name, check})
+}
+
+// Drain fails every following readiness report, so load balancers stop
+// routing traffic before the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Liveness(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.live...)
+	c.mu.RUnlock()
+
+	return c.run(ctx, checks)
+}
+
+func (c *Checker) Readiness(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append(append([]namedCheck(nil), c.live...), c.ready...)
+	c.mu.RUnlock()
+
+	checks = append(checks, namedCheck{_shutdownCheck, func(context.Context) error {
+		if c.draining.Load() {
+			return ErrShuttingDown
+		}
+		return nil
+	}})
+
+	return c.run(ctx, checks)
+}
+
+// run executes the checks concurrently.
+func (c *Checker) run(ctx context.Context, checks []namedCheck) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.runOne(ctx, check)
+		}(i, nc.check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, nc := range checks {
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+		report.Checks[nc.name] = results[i]
+	}
+
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := Result{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Cached runs check at most once per ttl and reports the last result in
+// between. It keeps checks that are expensive or rate limited, such as
+// provider probes, off the hot path of frequent readiness polls.
+func Cached(check Check, ttl time.Duration) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		lastErr error
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return lastErr
+		}
+
+		lastErr = check(ctx)
+		checked = time.Now()
+		return lastErr
+	}
+}
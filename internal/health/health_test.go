@@ -0,0 +1,62 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/health"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	c := health.New()
+	c.AddLivenessCheck("workers", func(context.Context) error { return nil })
+	c.AddReadinessCheck("postgres", func(context.Context) error { return errors.New("connection refused") })
+
+	live := c.Liveness(context.Background())
+	require.True(t, live.Healthy())
+	require.Len(t, live.Checks, 1)
+
+	ready := c.Readiness(context.Background())
+	require.False(t, ready.Healthy())
+	require.Equal(t, health.StatusOK, ready.Checks["workers"].Status)
+	require.Equal(t, health.StatusFail, ready.Checks["postgres"].Status)
+	require.Equal(t, "connection refused", ready.Checks["postgres"].Error)
+}
+
+func TestDrain(t *testing.T) {
+	c := health.New()
+	require.True(t, c.Readiness(context.Background()).Healthy())
+
+	c.Drain()
+
+	ready := c.Readiness(context.Background())
+	require.False(t, ready.Healthy())
+	require.Equal(t, health.ErrShuttingDown.Error(), ready.Checks["shutdown"].Error)
+	require.True(t, c.Liveness(context.Background()).Healthy())
+}
+
+func TestTimeout(t *testing.T) {
+	c := health.New(health.Timeout(10 * time.Millisecond))
+	c.AddReadinessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ready := c.Readiness(context.Background())
+	require.Equal(t, health.StatusFail, ready.Checks["slow"].Status)
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := health.Cached(func(context.Context) error {
+		calls++
+		return errors.New("quota exhausted")
+	}, time.Hour)
+
+	require.Error(t, check(context.Background()))
+	require.Error(t, check(context.Background()))
+	require.Equal(t, 1, calls)
+}
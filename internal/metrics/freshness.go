@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type pair struct {
+	base      string
+	secondary string
+}
+
+// freshnessCollector reports when each pair was last updated and how old its
+// rate is at scrape time.
+type freshnessCollector struct {
+	mu          sync.Mutex
+	lastUpdates map[pair]time.Time
+
+	lastUpdateDesc *prometheus.Desc
+	ageDesc        *prometheus.Desc
+}
+
+func newFreshnessCollector() *freshnessCollector {
+	return &freshnessCollector{
+		lastUpdates: make(map[pair]time.Time),
+		lastUpdateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "rate", "last_update_timestamp_seconds"),
+			"Time the rate of the pair was last updated.",
+			[]string{"base", "secondary"}, nil),
+		ageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "rate", "age_seconds"),
+			"Seconds since the rate of the pair was last updated.",
+			[]string{"base", "secondary"}, nil),
+	}
+}
+
+func (f *freshnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.lastUpdateDesc
+	ch <- f.ageDesc
+}
+
+func (f *freshnessCollector) Collect(ch chan<- prometheus.Metric) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	for p, at := range f.lastUpdates {
+		ch <- prometheus.MustNewConstMetric(f.lastUpdateDesc, prometheus.GaugeValue, float64(at.Unix()), p.base, p.secondary)
+		ch <- prometheus.MustNewConstMetric(f.ageDesc, prometheus.GaugeValue, now.Sub(at).Seconds(), p.base, p.secondary)
+	}
+}
+
+// OnUpdated implements exchangerates.UpdateListener.
+func (m *Metrics) OnUpdated(_ context.Context, record *exchangerates.Record) {
+	f := m.freshness
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := pair{record.Base, record.Secondary}
+	if record.Updated_At.After(f.lastUpdates[p]) {
+		f.lastUpdates[p] = record.Updated_At
+	}
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware counts and times every request by its route pattern.
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := c.Request.Method
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveUpstream is an httpx.Observer timing upstream calls and counting
+// their errors by failure class.
+func (m *Metrics) ObserveUpstream(apiName string, call httpx.Call, _ int, duration time.Duration, err error) {
+	label := call.Label
+	if label == "" {
+		label = call.Path
+	}
+
+	m.upstreamDuration.WithLabelValues(apiName, label).Observe(duration.Seconds())
+
+	if err != nil {
+		class := exchangerates.ClassifyFailure(err).Code
+		m.upstreamErrors.WithLabelValues(apiName, label, string(class)).Inc()
+	}
+}
@@ -0,0 +1,163 @@
+// Package metrics exposes the service's Prometheus metrics.
+//
+// Every metric is prefixed with "exchangerate_" followed by its subsystem,
+// durations are in seconds and timestamps in unix seconds:
+//
+//	exchangerate_http_requests_total{method,route,code}               counter
+//	exchangerate_http_request_duration_seconds{method,route}          histogram
+//	exchangerate_worker_queue_depth                                   gauge
+//	exchangerate_worker_busy                                          gauge
+//	exchangerate_worker_processed_total{result}                       counter
+//	exchangerate_upstream_request_duration_seconds{api,call}          histogram
+//	exchangerate_upstream_errors_total{api,call,class}                counter
+//	exchangerate_db_pool_connections{state}                           gauge
+//	exchangerate_db_pool_max_connections                              gauge
+//	exchangerate_db_pool_acquires_total                               counter
+//	exchangerate_db_pool_empty_acquires_total                         counter
+//	exchangerate_db_pool_canceled_acquires_total                      counter
+//	exchangerate_db_pool_acquire_duration_seconds_total               counter
+//	exchangerate_rate_last_update_timestamp_seconds{base,secondary}   gauge
+//	exchangerate_rate_age_seconds{base,secondary}                     gauge
+//
+// route is the gin route pattern, e.g. "/v1/exchangerates/:id", or
+// "unmatched". result is one of "updated", "quarantined" or "failed". call is
+// the httpx call label and class the exchangerates failure code.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "exchangerate"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	workerBusy      prometheus.Gauge
+	workerProcessed *prometheus.CounterVec
+
+	upstreamDuration *prometheus.HistogramVec
+	upstreamErrors   *prometheus.CounterVec
+
+	freshness *freshnessCollector
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+
+		workerBusy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "worker",
+			Name:      "busy",
+			Help:      "Workers currently processing a record.",
+		}),
+		workerProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "worker",
+			Name:      "processed_total",
+			Help:      "Records processed by workers, by resulting status.",
+		}, []string{"result"}),
+
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "upstream",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of calls to upstream APIs, by api and call.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"api", "call"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "upstream",
+			Name:      "errors_total",
+			Help:      "Failed calls to upstream APIs, by api, call and failure class.",
+		}, []string{"api", "call", "class"}),
+
+		freshness: newFreshnessCollector(),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.workerBusy,
+		m.workerProcessed,
+		m.upstreamDuration,
+		m.upstreamErrors,
+		m.freshness,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterQueueDepth reports the number of records waiting in the queue.
+func (m *Metrics) RegisterQueueDepth(depth func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: _namespace,
+		Subsystem: "worker",
+		Name:      "queue_depth",
+		Help:      "Records waiting in the queue for a worker.",
+	}, func() float64 { return float64(depth()) }))
+}
+
+// RegisterRecordsCache reports the lookups of the latest records cache.
+func (m *Metrics) RegisterRecordsCache(stats func() exchangerates.CacheStats) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "records_cache",
+			Name:      "hits_total",
+			Help:      "Latest record lookups served from the cache.",
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "records_cache",
+			Name:      "misses_total",
+			Help:      "Latest record lookups that queried the database.",
+		}, func() float64 { return float64(stats().Misses) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "records_cache",
+			Name:      "entries",
+			Help:      "Pairs kept in the cache, including expired ones not evicted yet.",
+		}, func() float64 { return float64(stats().Size) }),
+	)
+}
+
+// WorkerStarted and WorkerFinished track a worker processing a record.
+func (m *Metrics) WorkerStarted() {
+	m.workerBusy.Inc()
+}
+
+func (m *Metrics) WorkerFinished(result string) {
+	m.workerBusy.Dec()
+	m.workerProcessed.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/exchangerates"
+	"github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGinMiddleware_LabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New()
+
+	handler := gin.New()
+	handler.Use(m.GinMiddleware())
+	handler.GET("/v1/exchangerates/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/v1/exchangerates/a", "/v1/exchangerates/b", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/v1/exchangerates/:id", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestObserveUpstream_CountsErrorsByClass(t *testing.T) {
+	m := New()
+	call := httpx.Call{Path: "/latest", Label: "latest"}
+
+	m.ObserveUpstream("ExchangeRatesAPI", call, http.StatusOK, time.Millisecond, nil)
+	m.ObserveUpstream("ExchangeRatesAPI", call, http.StatusPaymentRequired, time.Millisecond, exchangeratesapi.ErrMaxAllowedAPICalls)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.upstreamErrors.WithLabelValues("ExchangeRatesAPI", "latest", "quota_exhausted")))
+	require.Equal(t, 1, testutil.CollectAndCount(m.upstreamDuration))
+}
+
+func TestFreshness(t *testing.T) {
+	m := New()
+	m.OnUpdated(context.Background(), &exchangerates.Record{Base: "EUR", Secondary: "USD", Updated_At: time.Unix(1711698845, 0)})
+
+	expected := `
+# HELP exchangerate_rate_last_update_timestamp_seconds Time the rate of the pair was last updated.
+# TYPE exchangerate_rate_last_update_timestamp_seconds gauge
+exchangerate_rate_last_update_timestamp_seconds{base="EUR",secondary="USD"} 1.711698845e+09
+`
+	require.NoError(t, testutil.CollectAndCompare(m.freshness, strings.NewReader(expected), "exchangerate_rate_last_update_timestamp_seconds"))
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector exports pgxpool statistics at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	connections     *prometheus.Desc
+	maxConnections  *prometheus.Desc
+	acquires        *prometheus.Desc
+	emptyAcquires   *prometheus.Desc
+	canceled        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+}
+
+// RegisterPool reports the statistics of the database connection pool.
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(_namespace, "db_pool", name), help, labels, nil)
+	}
+
+	m.registry.MustRegister(&poolCollector{
+		pool:            pool,
+		connections:     desc("connections", "Connections in the pool, by state: acquired, idle or constructing.", "state"),
+		maxConnections:  desc("max_connections", "Maximum size of the pool."),
+		acquires:        desc("acquires_total", "Successful connection acquires."),
+		emptyAcquires:   desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceled:        desc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	})
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.connections
+	ch <- p.maxConnections
+	ch <- p.acquires
+	ch <- p.emptyAcquires
+	ch <- p.canceled
+	ch <- p.acquireDuration
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := p.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(p.connections, prometheus.GaugeValue, float64(s.AcquiredConns()), "acquired")
+	ch <- prometheus.MustNewConstMetric(p.connections, prometheus.GaugeValue, float64(s.IdleConns()), "idle")
+	ch <- prometheus.MustNewConstMetric(p.connections, prometheus.GaugeValue, float64(s.ConstructingConns()), "constructing")
+	ch <- prometheus.MustNewConstMetric(p.maxConnections, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.canceled, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
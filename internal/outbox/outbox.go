@@ -0,0 +1,117 @@
+// Package outbox relays record lifecycle events, persisted transactionally
+// alongside the records, to downstream systems.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const _defaultBatchSize = 100
+
+// DefaultPollInterval is the default of PollInterval.
+const DefaultPollInterval = time.Second
+
+type Message struct {
+	Id          int64           `json:"id"`
+	AggregateId string          `json:"aggregate_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Created_At  time.Time       `json:"created_at"`
+}
+
+// Publisher delivers a single message downstream. Delivery is at-least-once:
+// consumers should deduplicate on Message.Id.
+type Publisher interface {
+	Publish(context.Context, Message) error
+}
+
+type Repo interface {
+	// Deliver passes up to limit pending messages, in insertion order, to fn
+	// and marks every message fn accepted as delivered. It stops at the first
+	// rejected message so ordering is preserved, and returns the number of
+	// delivered messages.
+	Deliver(ctx context.Context, limit int, fn func(context.Context, Message) error) (int, error)
+}
+
+type Relay struct {
+	repo         Repo
+	publisher    Publisher
+	batchSize    int
+	pollInterval time.Duration
+	logger       *slog.Logger
+}
+
+type Option func(*Relay)
+
+func BatchSize(size int) Option {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+func PollInterval(interval time.Duration) Option {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+func Logger(l *slog.Logger) Option {
+	return func(r *Relay) {
+		r.logger = l
+	}
+}
+
+func NewRelay(repo Repo, publisher Publisher, opts ...Option) *Relay {
+	r := &Relay{
+		repo:         repo,
+		publisher:    publisher,
+		batchSize:    _defaultBatchSize,
+		pollInterval: DefaultPollInterval,
+		logger:       slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Start runs the relay in the background until ctx is cancelled. The returned
+// channel is closed once the relay has stopped.
+func (r *Relay) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			n, err := r.repo.Deliver(ctx, r.batchSize, r.publisher.Publish)
+			if err != nil && ctx.Err() == nil {
+				// NoReturnErr: undelivered messages are retried on the next poll
+				r.logger.ErrorContext(ctx, "outbox relay error", slog.Any("error", err))
+			}
+
+			// A full batch means more messages are likely pending
+			if err == nil && n == r.batchSize {
+				timer.Reset(0)
+			} else {
+				timer.Reset(r.pollInterval)
+			}
+		}
+	}()
+
+	return done
+}
@@ -0,0 +1,96 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/outbox"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRepo struct {
+	mu      sync.Mutex
+	pending []outbox.Message
+}
+
+func (r *memoryRepo) Deliver(ctx context.Context, limit int, fn func(context.Context, outbox.Message) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivered := 0
+	for delivered < limit && len(r.pending) > 0 {
+		if err := fn(ctx, r.pending[0]); err != nil {
+			return delivered, err
+		}
+		r.pending = r.pending[1:]
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+type flakyPublisher struct {
+	mu        sync.Mutex
+	failures  int
+	published []int64
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, msg outbox.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("downstream unavailable")
+	}
+	p.published = append(p.published, msg.Id)
+	return nil
+}
+
+func TestRelay_DeliversInOrderAfterFailures(t *testing.T) {
+	repo := &memoryRepo{}
+	for id := int64(1); id <= 5; id++ {
+		repo.pending = append(repo.pending, outbox.Message{Id: id})
+	}
+	pub := &flakyPublisher{failures: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := outbox.NewRelay(repo, pub,
+		outbox.BatchSize(2),
+		outbox.PollInterval(time.Millisecond),
+	).Start(ctx)
+
+	require.Eventually(t, func() bool {
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		return len(pub.published) == 5
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, pub.published)
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	pub := outbox.NewWriterPublisher(&buf)
+
+	msg := outbox.Message{
+		Id:          1,
+		AggregateId: "identifier",
+		EventType:   "record.created",
+		Payload:     json.RawMessage(`{"base":"EUR"}`),
+	}
+	require.NoError(t, pub.Publish(context.Background(), msg))
+
+	var got outbox.Message
+	require.NoError(t, json.Unmarshal(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), &got))
+	require.Equal(t, msg.Id, got.Id)
+	require.JSONEq(t, `{"base":"EUR"}`, string(got.Payload))
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+)
+
+// writerPublisher writes every message as a single JSON line.
+type writerPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) Publisher {
+	return &writerPublisher{w: w}
+}
+
+func NewStdoutPublisher() Publisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+// NewFilePublisher appends messages to the file at path, creating it if needed.
+func NewFilePublisher(path string) (Publisher, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("outbox file publisher error: %w", err)
+	}
+
+	return NewWriterPublisher(f), f, nil
+}
+
+func (p *writerPublisher) Publish(_ context.Context, msg Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(b, '\n'))
+	return err
+}
+
+// httpPublisher POSTs every message as JSON to a fixed endpoint.
+type httpPublisher struct {
+	xcl *httpx.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) Publisher {
+	return &httpPublisher{
+		xcl: httpx.NewClient("OutboxPublisher", url, httpx.WithDefaultHTTPClientWithTimeout(timeout)),
+	}
+}
+
+func (p *httpPublisher) Publish(ctx context.Context, msg Message) error {
+	return p.xcl.Do(ctx, httpx.Call{
+		Method:  http.MethodPost,
+		Request: msg,
+		RequestHeaders: map[string]string{
+			"X-Event-Id":   strconv.FormatInt(msg.Id, 10),
+			"X-Event-Type": msg.EventType,
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/outbox"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// _relayLockKey is the advisory lock that serializes claims, so that a
+	// single relay across replicas holds claims at a time, which keeps
+	// delivery ordered.
+	_relayLockKey = 7_246_001
+	// _relayLease is how long claimed messages are left to a relay. It is
+	// renewed with every delivered message, so it only outlasts one publish.
+	_relayLease     = time.Minute
+	_releaseTimeout = 5 * time.Second
+)
+
+type outboxRepository struct {
+	connPool *pgxpool.Pool
+}
+
+func NewOutboxRepository(connPool *pgxpool.Pool) (*outboxRepository, error) {
+
+	if connPool == nil {
+		return nil, errors.New("provided connPool handle is nil")
+	}
+
+	return &outboxRepository{connPool}, nil
+}
+
+// Deliver claims the messages in a short transaction, then publishes them
+// without holding a connection, so that a slow publisher does not keep the
+// pool busy. Each delivered message is marked in its own statement.
+func (r *outboxRepository) Deliver(ctx context.Context, limit int, fn func(context.Context, outbox.Message) error) (int, error) {
+	messages, err := r.claim(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("claiming outbox messages is failed: %w", err)
+	}
+
+	for i, msg := range messages {
+		if err := fn(ctx, msg); err != nil {
+			// The failed message is retried on the next poll, not once the
+			// lease expired
+			return i, errors.Join(err, r.release(ctx, messages[i:]))
+		}
+
+		now := time.Now()
+		remaining := make([]int64, 0, len(messages)-i-1)
+		for _, next := range messages[i+1:] {
+			remaining = append(remaining, next.Id)
+		}
+
+		_, err := r.connPool.Exec(ctx, `WITH delivered AS (
+				UPDATE outbox SET delivered_at = $1, claimed_until = NULL WHERE id = $2
+			)
+			UPDATE outbox SET claimed_until = $3 WHERE id = ANY($4)`,
+			now, msg.Id, now.Add(_relayLease), remaining)
+		if err != nil {
+			// NoReturnErr: the message is published again, delivery is at-least-once
+			return i, fmt.Errorf("marking outbox message delivered is failed: %w", err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+// claim returns up to limit pending messages, in insertion order, claimed
+// for _relayLease. It returns none while another relay holds claims.
+func (r *outboxRepository) claim(ctx context.Context, limit int) ([]outbox.Message, error) {
+	var messages []outbox.Message
+
+	err := pgx.BeginFunc(ctx, r.connPool, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", _relayLockKey).Scan(&locked); err != nil {
+			return err
+		}
+
+		if !locked {
+			// NoReturnErr: another relay is claiming
+			return nil
+		}
+
+		now := time.Now()
+
+		var active bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM outbox WHERE delivered_at IS NULL AND claimed_until > $1)", now).Scan(&active); err != nil {
+			return err
+		}
+
+		if active {
+			// NoReturnErr: another relay is delivering
+			return nil
+		}
+
+		rows, err := tx.Query(ctx, `UPDATE outbox SET claimed_until = $1
+			WHERE id IN (SELECT id FROM outbox WHERE delivered_at IS NULL ORDER BY id LIMIT $2)
+			RETURNING id, aggregate_id, event_type, payload, created_at`,
+			now.Add(_relayLease), limit)
+		if err != nil {
+			return err
+		}
+
+		messages, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (outbox.Message, error) {
+			var msg outbox.Message
+			err := row.Scan(&msg.Id, &msg.AggregateId, &msg.EventType, &msg.Payload, &msg.Created_At)
+			return msg, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Id < messages[j].Id })
+
+	return messages, nil
+}
+
+// release gives up the claims of messages that were not delivered. It runs
+// on shutdown too, so it is not cancelled along with ctx.
+func (r *outboxRepository) release(ctx context.Context, messages []outbox.Message) error {
+	ids := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.Id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _releaseTimeout)
+	defer cancel()
+
+	if _, err := r.connPool.Exec(ctx, "UPDATE outbox SET claimed_until = NULL WHERE id = ANY($1)", ids); err != nil {
+		return fmt.Errorf("releasing outbox messages is failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const _sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore keeps buckets in process, for single instance deployments.
+func NewMemoryStore() Store {
+	return newMemoryStore(time.Now)
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{
+		buckets:   make(map[string]*bucket),
+		now:       now,
+		lastSweep: now(),
+	}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+	b.limit = limit
+
+	if b.tokens < 1 {
+		return NewResult(false, b.tokens, limit), nil
+	}
+
+	b.tokens--
+	return NewResult(true, b.tokens, limit), nil
+}
+
+// sweep drops buckets that have refilled completely, since they are
+// indistinguishable from new ones.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < _sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.limit) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package ratelimit limits requests per client with token buckets.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit refills a bucket of Burst tokens at Rate tokens per Period.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseLimit parses limits such as "30/m", i.e. 30 requests per minute.
+// Period units are s, m and h. An empty string or "0" disables limiting.
+func ParseLimit(s string, burst int) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	rate, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	n, err := strconv.Atoi(rate)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	period, ok := periods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: unknown period in %q", ErrInvalidLimit, s)
+	}
+
+	if burst <= 0 {
+		burst = n
+	}
+
+	return Limit{Rate: n, Period: period, Burst: burst}, nil
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// perSecond is the refill rate in tokens per second.
+func (l Limit) perSecond() float64 {
+	return float64(l.Rate) / l.Period.Seconds()
+}
+
+// Result is the state of a bucket after taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, when not allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Key identifies the bucket of a client and group.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter applies the limit of a route group to the bucket of a client.
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+// Allow takes a token from the client's bucket of the group. Groups without
+// an enabled limit always allow.
+func (l *Limiter) Allow(ctx context.Context, group string, client string) (Result, bool, error) {
+	limit, ok := l.limits[group]
+	if !ok || !limit.Enabled() {
+		return Result{Allowed: true}, false, nil
+	}
+
+	res, err := l.store.Take(ctx, group+":"+client, limit)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("rate limiting is failed: %w", err)
+	}
+	return res, true, nil
+}
+
+// refill returns the tokens of a bucket after elapsed time.
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.perSecond())
+}
+
+// NewResult describes a bucket left with tokens after a take.
+func NewResult(allowed bool, tokens float64, limit Limit) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.perSecond()),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.perSecond())
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
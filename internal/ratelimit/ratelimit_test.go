@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    string
+		burst    int
+		expected Limit
+		wantErr  bool
+	}{
+		{"per minute", "30/m", 0, Limit{Rate: 30, Period: time.Minute, Burst: 30}, false},
+		{"with burst", "10/s", 50, Limit{Rate: 10, Period: time.Second, Burst: 50}, false},
+		{"disabled", "0", 0, Limit{}, false},
+		{"empty", "", 0, Limit{}, false},
+		{"unknown period", "10/d", 0, Limit{}, true},
+		{"missing period", "10", 0, Limit{}, true},
+		{"negative", "-1/m", 0, Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, err := ParseLimit(tt.limit, tt.burst)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidLimit)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Now()
+	s := newMemoryStore(func() time.Time { return now })
+	limit := Limit{Rate: 1, Period: time.Second, Burst: 2}
+
+	res, err := s.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 1, res.Remaining)
+	require.Equal(t, time.Second, res.Reset)
+
+	res, _ = s.Take(context.Background(), "client", limit)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	res, _ = s.Take(context.Background(), "client", limit)
+	require.False(t, res.Allowed)
+	require.Equal(t, time.Second, res.RetryAfter)
+
+	// Other clients have their own bucket
+	res, _ = s.Take(context.Background(), "other", limit)
+	require.True(t, res.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	res, _ = s.Take(context.Background(), "client", limit)
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	res, _ = s.Take(context.Background(), "client", limit)
+	require.True(t, res.Allowed)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Now()
+	s := newMemoryStore(func() time.Time { return now })
+	limit := Limit{Rate: 1, Period: time.Second, Burst: 1}
+
+	_, _ = s.Take(context.Background(), "client", limit)
+	require.Len(t, s.buckets, 1)
+
+	now = now.Add(2 * _sweepInterval)
+	_, _ = s.Take(context.Background(), "other", limit)
+	require.Len(t, s.buckets, 1)
+	require.Contains(t, s.buckets, "other")
+}
+
+func TestLimiter_DisabledGroup(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), map[string]Limit{"read": {}})
+
+	_, limited, err := l.Allow(context.Background(), "read", "client")
+	require.NoError(t, err)
+	require.False(t, limited)
+
+	_, limited, err = l.Allow(context.Background(), "unknown", "client")
+	require.NoError(t, err)
+	require.False(t, limited)
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/internal/ratelimit"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	_sweepInterval = 10 * time.Minute
+	// _staleAfter drops buckets that have been idle long enough to be full.
+	_staleAfter = 24 * time.Hour
+)
+
+// _refilled is the token count of the existing bucket refilled up to now.
+const _refilled = "LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM (NOW() - rl.updated_at))::float8 * $3::float8)"
+
+// Buckets are refilled and taken from in a single statement using the
+// database clock, so replicas share them without races or clock skew.
+const _takeQuery = `INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::float8 - 1, TRUE, NOW())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = ` + _refilled + ` - CASE WHEN ` + _refilled + ` >= 1 THEN 1 ELSE 0 END,
+		allowed = ` + _refilled + ` >= 1,
+		updated_at = NOW()
+	RETURNING tokens, allowed`
+
+type rateLimitStore struct {
+	connPool *pgxpool.Pool
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimitStore(connPool *pgxpool.Pool) (*rateLimitStore, error) {
+
+	if connPool == nil {
+		return nil, errors.New("provided connPool handle is nil")
+	}
+
+	return &rateLimitStore{connPool: connPool, lastSweep: time.Now()}, nil
+}
+
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	s.sweep()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	perSecond := float64(limit.Rate) / limit.Period.Seconds()
+	err := s.connPool.QueryRow(ctx, _takeQuery, key, limit.Burst, perSecond).Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return ratelimit.NewResult(allowed, tokens, limit), nil
+}
+
+// sweep deletes stale buckets in the background at most once per interval.
+func (s *rateLimitStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastSweep) < _sweepInterval {
+		return
+	}
+	s.lastSweep = time.Now()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		// NoReturnErr: stale buckets are deleted on the next sweep
+		_, _ = s.connPool.Exec(ctx, "DELETE FROM rate_limits WHERE updated_at < NOW() - $1 * INTERVAL '1 second'", _staleAfter.Seconds())
+	}()
+}
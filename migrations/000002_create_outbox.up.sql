@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS outbox(
+	id BIGSERIAL PRIMARY KEY,
+	aggregate_id VARCHAR(50) NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	payload JSONB NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMP
+);
+
+CREATE INDEX outbox_pending_idx ON outbox(id) WHERE delivered_at IS NULL;
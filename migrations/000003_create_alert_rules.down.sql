@@ -0,0 +1 @@
+DROP TABLE alert_rules;
\ No newline at end of file
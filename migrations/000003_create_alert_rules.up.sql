@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS alert_rules(
+	id SERIAL PRIMARY KEY,
+	base VARCHAR(5) NOT NULL,
+	secondary VARCHAR(5) NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	threshold NUMERIC NOT NULL,
+	window_seconds INTEGER NOT NULL DEFAULT 0,
+	cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+	last_value NUMERIC,
+	last_triggered_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX alert_rules_base_secondary_idx ON alert_rules(base, secondary);
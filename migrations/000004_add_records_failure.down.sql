@@ -0,0 +1,3 @@
+ALTER TABLE records
+	DROP COLUMN failure_code,
+	DROP COLUMN failure_message;
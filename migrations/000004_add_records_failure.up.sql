@@ -0,0 +1,3 @@
+ALTER TABLE records
+	ADD COLUMN failure_code VARCHAR(50) NOT NULL DEFAULT '',
+	ADD COLUMN failure_message TEXT NOT NULL DEFAULT '';
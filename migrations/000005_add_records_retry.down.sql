@@ -0,0 +1,5 @@
+DROP INDEX records_next_attempt_at_idx;
+
+ALTER TABLE records
+	DROP COLUMN attempts,
+	DROP COLUMN next_attempt_at;
@@ -0,0 +1,5 @@
+ALTER TABLE records
+	ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN next_attempt_at TIMESTAMP;
+
+CREATE INDEX records_next_attempt_at_idx ON records(next_attempt_at) WHERE next_attempt_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE records DROP COLUMN request_id;
@@ -0,0 +1 @@
+ALTER TABLE records ADD COLUMN request_id VARCHAR(128) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE records DROP COLUMN created_by;
+
+DROP TABLE IF EXISTS api_keys;
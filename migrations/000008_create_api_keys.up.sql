@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+	id SERIAL PRIMARY KEY,
+	client VARCHAR(100) NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	key_hash CHAR(64) UNIQUE NOT NULL,
+	scopes TEXT[] NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+
+ALTER TABLE records ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS rate_limits(
+	key VARCHAR(255) PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	allowed BOOLEAN NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits(updated_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+	client VARCHAR(100) NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	identifier VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (client, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys(client, expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS records_base_secondary_updated_at_idx;
+
+ALTER TABLE records DROP COLUMN source;
@@ -0,0 +1,3 @@
+ALTER TABLE records ADD COLUMN source VARCHAR(16) NOT NULL DEFAULT 'provider';
+
+CREATE INDEX records_base_secondary_updated_at_idx ON records(base, secondary, updated_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS records_pending_idx;
+
+ALTER TABLE records DROP COLUMN claimed_until;
@@ -0,0 +1,3 @@
+ALTER TABLE records ADD COLUMN claimed_until TIMESTAMP;
+
+CREATE INDEX records_pending_idx ON records(id) WHERE status = 1;
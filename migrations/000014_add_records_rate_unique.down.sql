@@ -0,0 +1 @@
+DROP INDEX IF EXISTS records_rate_unique_idx;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS records_rate_unique_idx ON records(base, secondary, updated_at) WHERE status = 2;
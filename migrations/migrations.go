@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations of the database schema, so
+// that the binary migrates from any working directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
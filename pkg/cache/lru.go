@@ -0,0 +1,122 @@
+// Package cache provides a bounded in-memory cache.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a cache bounded in size that evicts the least recently used entry
+// once it is full. Entries expire after their TTL, zero meaning never.
+type LRU[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[K]*list.Element
+	order *list.List
+	now   func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// New returns a cache of at most size entries, which expire after ttl.
+func New[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	if size < 1 {
+		size = 1
+	}
+
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(el)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Set stores the value with the TTL of the cache.
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores the value with its own TTL, zero meaning it never expires.
+func (c *LRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Purge deletes every entry.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element, c.size)
+	c.order.Init()
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_Evicts(t *testing.T) {
+	c := New[string, int](2, 0)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Reading a makes b the least recently used entry
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	c.Set("c", 3)
+	require.Equal(t, 2, c.Len())
+
+	_, ok = c.Get("b")
+	require.False(t, ok)
+
+	v, ok = c.Get("c")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+
+	c.Delete("c")
+	_, ok = c.Get("c")
+	require.False(t, ok)
+}
+
+func TestLRU_Expires(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Second)
+	c.SetWithTTL("c", 3, 0)
+
+	now = now.Add(time.Second)
+	_, ok := c.Get("b")
+	require.False(t, ok)
+
+	_, ok = c.Get("a")
+	require.True(t, ok)
+
+	now = now.Add(time.Hour)
+	_, ok = c.Get("a")
+	require.False(t, ok)
+
+	_, ok = c.Get("c")
+	require.True(t, ok)
+	require.Equal(t, 1, c.Len())
+}
+
+func TestLRU_Purge(t *testing.T) {
+	c := New[string, int](10, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Purge()
+	require.Zero(t, c.Len())
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	c.Set("a", 1)
+	require.Equal(t, 1, c.Len())
+}
@@ -1,8 +1,10 @@
 package env
 
 import (
-	"log"
+	"errors"
+	"io/fs"
 	"os"
+	"sync"
 
 	"github.com/joho/godotenv"
 )
@@ -10,14 +12,60 @@ import (
 func MustGet(key string) string {
 	val := os.Getenv(key)
 	if val == "" && key != "PORT" {
-		panic("env key " + val + "cannot found")
+		panic("env key " + key + " cannot be found")
 	}
 	return val
 }
 
-func CheckDotEnv(path string) {
-	err := godotenv.Load(path)
+// Get returns the value of key or fallback when it is unset or empty.
+func Get(key string, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// dotEnv holds the variables set from a .env file, which a later load of the
+// file may change, unlike the variables of the environment.
+var dotEnv = struct {
+	mu  sync.Mutex
+	set map[string]bool
+}{set: map[string]bool{}}
+
+// LoadDotEnv sets the variables of the .env file at path that are not set
+// already by the environment. Loading the file again, e.g. on reload,
+// updates the variables set from it and unsets the ones removed from it. A
+// missing file is not an error.
+func LoadDotEnv(path string) error {
+	vars, err := godotenv.Read(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// NoReturnErr: the environment is configured otherwise
+		vars, err = nil, nil
+	}
 	if err != nil {
-		log.Fatalln("Error in loading .env file:", err)
+		return err
+	}
+
+	dotEnv.mu.Lock()
+	defer dotEnv.mu.Unlock()
+
+	for key := range dotEnv.set {
+		if _, ok := vars[key]; !ok {
+			if err := os.Unsetenv(key); err != nil {
+				return err
+			}
+			delete(dotEnv.set, key)
+		}
+	}
+
+	for key, val := range vars {
+		if _, ok := os.LookupEnv(key); ok && !dotEnv.set[key] {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return err
+		}
+		dotEnv.set[key] = true
 	}
+	return nil
 }
@@ -1,6 +1,9 @@
 package exchangeratesapi
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Client interface {
 	/// GetSupportedCurrencies is
@@ -8,6 +11,11 @@ type Client interface {
 
 	/// GetLatestRate is
 	GetLatestRate(ctx context.Context, base string, target string) (*Rate, error)
+	/// GetHistoricalRate returns the rate at the end of the date, in UTC
+	GetHistoricalRate(ctx context.Context, date time.Time, base string, target string) (*Rate, error)
+
+	/// Quota returns the API usage reported by the provider for the key used last
+	Quota() Quota
 }
 
 type Rate struct {
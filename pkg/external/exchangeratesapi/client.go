@@ -3,7 +3,9 @@ package exchangeratesapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -11,35 +13,67 @@ import (
 	"net/url"
 
 	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
 )
 
-const (
-	BASE_URL = "http://api.exchangeratesapi.io"
-	APIKey   = "00da5b7225d0ad29aa49021a9b6bc022"
-)
+const BASE_URL = "http://api.exchangeratesapi.io"
+
+// DefaultTimeout is the default of WithTimeout.
+const DefaultTimeout = time.Minute
 
 type client struct {
 	xcl    httpx.Client
-	apiKey string
+	keys   *keyRing
+	quota  quotaTracker
+	logger *slog.Logger
 }
 
 type clientOption struct {
-	debug   bool
-	baseURL string
-	apiKey  string
+	debug       bool
+	baseURL     string
+	keys        Keys
+	keyCooldown time.Duration
+	timeout     time.Duration
+	observers   []httpx.Observer
+	tracing     bool
+	logger      *slog.Logger
 }
 
 type Option func(option *clientOption)
 
-func withBaseURL(URL string) Option {
+// WithBaseURL sets the URL of the API, BASE_URL by default.
+func WithBaseURL(URL string) Option {
 	return func(co *clientOption) {
 		co.baseURL = URL
 	}
 }
 
-func withAPIKey(apiKey string) Option {
+// WithAPIKey sets the access key of the API.
+func WithAPIKey(apiKey string) Option {
+	return WithAPIKeys(StaticKeys(apiKey))
+}
+
+// WithAPIKeys sets the access keys of the API. Calls rejected with
+// ErrInvalidAPIKey or ErrMaxAllowedAPICalls are made again with the next
+// key, and the rejected key is tried last until its cooldown is over.
+func WithAPIKeys(keys Keys) Option {
 	return func(co *clientOption) {
-		co.apiKey = apiKey
+		co.keys = keys
+	}
+}
+
+// WithKeyCooldown sets how long a rejected key is tried last, an hour by
+// default.
+func WithKeyCooldown(d time.Duration) Option {
+	return func(co *clientOption) {
+		co.keyCooldown = d
+	}
+}
+
+// WithTimeout sets the timeout of calls to the API, DefaultTimeout by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(co *clientOption) {
+		co.timeout = timeout
 	}
 }
 
@@ -49,21 +83,50 @@ func withDebug() Option {
 	}
 }
 
-func NewProvider() (Client, error) {
-	return new(withAPIKey(APIKey))
+// WithObserver notifies o after every call to the API.
+func WithObserver(o httpx.Observer) Option {
+	return func(co *clientOption) {
+		co.observers = append(co.observers, o)
+	}
+}
+
+// WithTracing traces every call to the API.
+func WithTracing() Option {
+	return func(co *clientOption) {
+		co.tracing = true
+	}
+}
+
+// WithLogger sets the logger used for debug output.
+func WithLogger(l *slog.Logger) Option {
+	return func(co *clientOption) {
+		co.logger = l
+	}
+}
+
+// NewProvider returns a client of the API, which requires WithAPIKey or
+// WithAPIKeys.
+func NewProvider(opts ...Option) (Client, error) {
+	return new(opts...)
 }
 
 func new(opts ...Option) (Client, error) {
 	clOpts := clientOption{
-		baseURL: BASE_URL,
+		baseURL:     BASE_URL,
+		keyCooldown: DefaultKeyCooldown,
+		timeout:     DefaultTimeout,
 	}
 
 	for _, o := range opts {
 		o(&clOpts)
 	}
 
+	if clOpts.keys == nil {
+		return nil, ErrNoAPIKey
+	}
+
 	httpxOptions := []httpx.ClientOption{
-		httpx.WithDefaultHTTPClientWithTimeout(time.Minute),
+		httpx.WithDefaultHTTPClientWithTimeout(clOpts.timeout),
 		httpx.WithParseErrResponse(parseError),
 	}
 
@@ -71,16 +134,62 @@ func new(opts ...Option) (Client, error) {
 		httpxOptions = append(httpxOptions, httpx.WithDebug())
 	}
 
+	if clOpts.logger != nil {
+		httpxOptions = append(httpxOptions, httpx.WithLogger(clOpts.logger))
+	}
+
+	if clOpts.tracing {
+		httpxOptions = append(httpxOptions, httpx.WithTracing())
+	}
+
+	for _, o := range clOpts.observers {
+		httpxOptions = append(httpxOptions, httpx.WithObserver(o))
+	}
+
 	return &client{
 		xcl: *httpx.NewClient(
 			"ExchangeRatesAPI",
 			clOpts.baseURL,
 			httpxOptions...,
 		),
-		apiKey: clOpts.apiKey,
+		keys:   newKeyRing(clOpts.keys, clOpts.keyCooldown),
+		logger: clOpts.logger,
 	}, nil
 }
 
+// do makes the call with the first key, and with the next ones while the
+// key is rejected.
+func (c *client) do(ctx context.Context, call httpx.Call) error {
+	keys := c.keys.candidates(time.Now())
+	if len(keys) == 0 {
+		return ErrNoAPIKey
+	}
+
+	var err error
+	for i, key := range keys {
+		call.Query.Set("access_key", key)
+		call.ResponseHeaders = make(url.Values)
+
+		err = c.xcl.Do(ctx, call)
+		c.quota.observe(call.ResponseHeaders)
+
+		if !errors.Is(err, ErrInvalidAPIKey) && !errors.Is(err, ErrMaxAllowedAPICalls) {
+			if err == nil {
+				c.keys.succeed(key)
+			}
+			return err
+		}
+
+		c.keys.fail(key, time.Now())
+		if c.logger != nil && i+1 < len(keys) {
+			// NoReturnErr: the call is made again with the next key
+			c.logger.WarnContext(ctx, "provider key rejected, failing over", logger.Err(err), slog.Int("key", i+1), slog.Int("keys", len(keys)))
+		}
+	}
+
+	return err
+}
+
 func (c *client) GetSupportedCurrencies(ctx context.Context) ([]string, error) {
 
 	resp := struct {
@@ -90,15 +199,15 @@ func (c *client) GetSupportedCurrencies(ctx context.Context) ([]string, error) {
 	call := httpx.Call{
 		Method:   http.MethodGet,
 		Path:     "/symbols",
-		Query:    url.Values{"access_key": []string{c.apiKey}},
+		Label:    "symbols",
+		Query:    make(url.Values),
 		Response: &resp,
 		RequestHeaders: map[string]string{
 			"Accept": "application/json",
 		},
 	}
 
-	err := c.xcl.Do(ctx, call)
-	if err != nil {
+	if err := c.do(ctx, call); err != nil {
 		return nil, err
 	}
 
@@ -112,6 +221,15 @@ func (c *client) GetSupportedCurrencies(ctx context.Context) ([]string, error) {
 }
 
 func (c *client) GetLatestRate(ctx context.Context, base string, target string) (*Rate, error) {
+	return c.getRate(ctx, "/latest", "latest", base, target)
+}
+
+func (c *client) GetHistoricalRate(ctx context.Context, date time.Time, base string, target string) (*Rate, error) {
+	return c.getRate(ctx, "/"+date.Format(time.DateOnly), "historical", base, target)
+}
+
+// getRate calls the latest and historical endpoints, which answer alike.
+func (c *client) getRate(ctx context.Context, path string, label string, base string, target string) (*Rate, error) {
 	base = strings.TrimSpace(strings.ToUpper(base))
 	target = strings.TrimSpace(strings.ToUpper(target))
 
@@ -123,20 +241,19 @@ func (c *client) GetLatestRate(ctx context.Context, base string, target string)
 
 	call := httpx.Call{
 		Method:   http.MethodGet,
-		Path:     "/latest",
+		Path:     path,
+		Label:    label,
 		Response: &resp,
 		Query: url.Values{
-			"access_key": []string{c.apiKey},
-			"base":       []string{base},
-			"symbols":    []string{target},
+			"base":    []string{base},
+			"symbols": []string{target},
 		},
 		RequestHeaders: map[string]string{
 			"Accept": "application/json",
 		},
 	}
 
-	err := c.xcl.Do(ctx, call)
-	if err != nil {
+	if err := c.do(ctx, call); err != nil {
 		return nil, err
 	}
 
@@ -155,6 +272,10 @@ func (c *client) GetLatestRate(ctx context.Context, base string, target string)
 	return nil, ErrNotSupportedTargetCurrency
 }
 
+func (c *client) Quota() Quota {
+	return c.quota.get()
+}
+
 type errorMsg struct {
 	Err Error `json:"error"`
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -152,8 +153,8 @@ func TestClient_GetLatestRate(t *testing.T) {
 
 			ctx := context.Background()
 			c, err := new(
-				withAPIKey(testAPIKey),
-				withBaseURL(srv.URL),
+				WithAPIKey(testAPIKey),
+				WithBaseURL(srv.URL),
 			)
 
 			require.NoError(t, err)
@@ -171,13 +172,95 @@ func TestClient_GetLatestRate(t *testing.T) {
 	}
 }
 
+func TestClient_GetHistoricalRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2024-03-01", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("symbols"))
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.Write([]byte(`{
+			"success": true,
+			"historical": true,
+			"date": "2024-03-01",
+			"timestamp": 1709337599,
+			"base": "EUR",
+			"rates": {
+				"USD": 1.083791
+			}
+		}`))
+	}))
+	defer srv.Close()
+
+	c, err := new(
+		WithAPIKey(testAPIKey),
+		WithBaseURL(srv.URL),
+	)
+	require.NoError(t, err)
+
+	rate, err := c.GetHistoricalRate(context.Background(), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "eur", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1709337599), rate.Timestamp)
+	assert.Equal(t, 1.083791, rate.Value)
+}
+
+func TestClient_KeyFailover(t *testing.T) {
+	var used []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("access_key")
+		used = append(used, key)
+
+		switch key {
+		case "revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"code": "invalid_access_key", "message": "invalid"}}`))
+		case "exhausted":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"code": "max_requests_reached", "message": "exhausted"}}`))
+		default:
+			w.Write([]byte(`{"success": true, "timestamp": 1709337599, "base": "EUR", "rates": {"USD": 1.08}}`))
+		}
+	}))
+	defer srv.Close()
+
+	keys := []string{"revoked", "exhausted", "valid"}
+	c, err := new(
+		WithAPIKeys(func() []string { return keys }),
+		WithBaseURL(srv.URL),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = c.GetLatestRate(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"revoked", "exhausted", "valid"}, used)
+
+	// Rejected keys are tried last until their cooldown is over
+	used = nil
+	_, err = c.GetLatestRate(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"valid"}, used)
+
+	// Rotated keys are used at once
+	keys = []string{"rotated"}
+	used = nil
+	_, err = c.GetLatestRate(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"rotated"}, used)
+
+	keys = []string{"revoked", "exhausted"}
+	_, err = c.GetLatestRate(ctx, "EUR", "USD")
+	require.ErrorIs(t, err, ErrMaxAllowedAPICalls)
+
+	_, err = new(WithBaseURL(srv.URL))
+	require.ErrorIs(t, err, ErrNoAPIKey)
+}
+
 func TestClient_GetLatestRate_Integration(t *testing.T) {
 	t.Skip("This is an integration test for ExchangeRates API to get latest rate for pair")
 
 	ctx := context.Background()
 
 	c, err := new(
-		withAPIKey(testAPIKey),
+		WithAPIKey(testAPIKey),
 		withDebug(),
 	)
 
@@ -262,8 +345,8 @@ func TestClient_GetSupportedCurrencies(t *testing.T) {
 
 			ctx := context.Background()
 			c, err := new(
-				withAPIKey(testAPIKey),
-				withBaseURL(srv.URL),
+				WithAPIKey(testAPIKey),
+				WithBaseURL(srv.URL),
 				withDebug(),
 			)
 
@@ -287,7 +370,7 @@ func TestClient_GetSupportedCurrencies_Integration(t *testing.T) {
 	ctx := context.Background()
 
 	c, err := new(
-		withAPIKey(testAPIKey),
+		WithAPIKey(testAPIKey),
 		withDebug(),
 	)
 
@@ -7,6 +7,7 @@ var (
 	ErrNotSupportedTargetCurrency = errors.New("provided target currency not supported")
 	ErrMaxAllowedAPICalls         = errors.New("the maximum allowed API amount of monthly API requests has been reached")
 	ErrInvalidAPIKey              = errors.New("no API Key was specified or an invalid API Key was specified")
+	ErrNoAPIKey                   = errors.New("no API key is configured")
 	ErrBaseCurrencyRestricted     = errors.New("base currency restricted")
 )
 
@@ -0,0 +1,82 @@
+package exchangeratesapi
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultKeyCooldown is the default of WithKeyCooldown.
+const DefaultKeyCooldown = time.Hour
+
+// Keys returns the API keys, in order of preference. It is called before
+// every call to the API, so that rotated keys are used at once.
+type Keys func() []string
+
+// StaticKeys always returns keys.
+func StaticKeys(keys ...string) Keys {
+	return func() []string {
+		return keys
+	}
+}
+
+// keyRing orders the keys so that keys rejected by the API are tried last
+// until their cooldown is over.
+type keyRing struct {
+	keys     Keys
+	cooldown time.Duration
+
+	mu     sync.Mutex
+	failed map[string]time.Time
+}
+
+func newKeyRing(keys Keys, cooldown time.Duration) *keyRing {
+	return &keyRing{
+		keys:     keys,
+		cooldown: cooldown,
+		failed:   make(map[string]time.Time),
+	}
+}
+
+// candidates returns the keys to try in order: those that did not fail
+// recently, then the others. Failures of keys that were removed, or whose
+// cooldown is over, are forgotten.
+func (k *keyRing) candidates(now time.Time) []string {
+	keys := k.keys()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	current := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		current[key] = true
+	}
+	for key, at := range k.failed {
+		if !current[key] || now.Sub(at) >= k.cooldown {
+			delete(k.failed, key)
+		}
+	}
+
+	ordered := make([]string, 0, len(keys))
+	var cooling []string
+	for _, key := range keys {
+		if _, ok := k.failed[key]; ok {
+			cooling = append(cooling, key)
+			continue
+		}
+		ordered = append(ordered, key)
+	}
+
+	return append(ordered, cooling...)
+}
+
+func (k *keyRing) fail(key string, now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.failed[key] = now
+}
+
+func (k *keyRing) succeed(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.failed, key)
+}
@@ -0,0 +1,25 @@
+package exchangeratesapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRing_Candidates(t *testing.T) {
+	keys := []string{"first", "second", "third"}
+	k := newKeyRing(func() []string { return keys }, time.Hour)
+	now := time.Now()
+
+	k.fail("first", now)
+	k.fail("second", now.Add(-2*time.Hour))
+	require.Equal(t, []string{"second", "third", "first"}, k.candidates(now))
+	// Failures past their cooldown are forgotten
+	require.NotContains(t, k.failed, "second")
+
+	// Failures of removed keys are forgotten
+	keys = []string{"third"}
+	require.Equal(t, []string{"third"}, k.candidates(now))
+	require.Empty(t, k.failed)
+}
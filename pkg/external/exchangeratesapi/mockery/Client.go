@@ -7,6 +7,8 @@ import (
 
 	exchangeratesapi "github.com/ZakirAvrora/exchange-rate/pkg/external/exchangeratesapi"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -22,6 +24,63 @@ func (_m *Client) EXPECT() *Client_Expecter {
 	return &Client_Expecter{mock: &_m.Mock}
 }
 
+// GetHistoricalRate provides a mock function with given fields: ctx, date, base, target
+func (_m *Client) GetHistoricalRate(ctx context.Context, date time.Time, base string, target string) (*exchangeratesapi.Rate, error) {
+	ret := _m.Called(ctx, date, base, target)
+
+	var r0 *exchangeratesapi.Rate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, string, string) (*exchangeratesapi.Rate, error)); ok {
+		return rf(ctx, date, base, target)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, string, string) *exchangeratesapi.Rate); ok {
+		r0 = rf(ctx, date, base, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*exchangeratesapi.Rate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, string, string) error); ok {
+		r1 = rf(ctx, date, base, target)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_GetHistoricalRate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHistoricalRate'
+type Client_GetHistoricalRate_Call struct {
+	*mock.Call
+}
+
+// GetHistoricalRate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - date time.Time
+//   - base string
+//   - target string
+func (_e *Client_Expecter) GetHistoricalRate(ctx interface{}, date interface{}, base interface{}, target interface{}) *Client_GetHistoricalRate_Call {
+	return &Client_GetHistoricalRate_Call{Call: _e.mock.On("GetHistoricalRate", ctx, date, base, target)}
+}
+
+func (_c *Client_GetHistoricalRate_Call) Run(run func(ctx context.Context, date time.Time, base string, target string)) *Client_GetHistoricalRate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *Client_GetHistoricalRate_Call) Return(_a0 *exchangeratesapi.Rate, _a1 error) *Client_GetHistoricalRate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_GetHistoricalRate_Call) RunAndReturn(run func(context.Context, time.Time, string, string) (*exchangeratesapi.Rate, error)) *Client_GetHistoricalRate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetLatestRate provides a mock function with given fields: ctx, base, target
 func (_m *Client) GetLatestRate(ctx context.Context, base string, target string) (*exchangeratesapi.Rate, error) {
 	ret := _m.Called(ctx, base, target)
@@ -132,6 +191,47 @@ func (_c *Client_GetSupportedCurrencies_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// Quota provides a mock function with given fields:
+func (_m *Client) Quota() exchangeratesapi.Quota {
+	ret := _m.Called()
+
+	var r0 exchangeratesapi.Quota
+	if rf, ok := ret.Get(0).(func() exchangeratesapi.Quota); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(exchangeratesapi.Quota)
+	}
+
+	return r0
+}
+
+// Client_Quota_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Quota'
+type Client_Quota_Call struct {
+	*mock.Call
+}
+
+// Quota is a helper method to define mock.On call
+func (_e *Client_Expecter) Quota() *Client_Quota_Call {
+	return &Client_Quota_Call{Call: _e.mock.On("Quota")}
+}
+
+func (_c *Client_Quota_Call) Run(run func()) *Client_Quota_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Client_Quota_Call) Return(_a0 exchangeratesapi.Quota) *Client_Quota_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_Quota_Call) RunAndReturn(run func() exchangeratesapi.Quota) *Client_Quota_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewClient interface {
 	mock.TestingT
 	Cleanup(func())
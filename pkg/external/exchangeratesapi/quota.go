@@ -0,0 +1,67 @@
+package exchangeratesapi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_limitHeaderPrefix     = "x-ratelimit-limit"
+	_remainingHeaderPrefix = "x-ratelimit-remaining"
+)
+
+// Quota is the API usage reported by the provider in the rate limit headers
+// of its latest response. Limit and Remaining are nil until the provider has
+// reported them.
+type Quota struct {
+	Limit      *int
+	Remaining  *int
+	Calls      int64
+	Updated_At time.Time
+}
+
+type quotaTracker struct {
+	mu    sync.Mutex
+	quota Quota
+}
+
+// observe records a response; headers is nil when none was received.
+func (t *quotaTracker) observe(headers url.Values) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.quota.Calls++
+
+	for name, values := range headers {
+		if len(values) == 0 {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(values[0]))
+		if err != nil {
+			// NoReturnErr: header is not a counter
+			continue
+		}
+
+		name = strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(name, _limitHeaderPrefix):
+			t.quota.Limit = &n
+		case strings.HasPrefix(name, _remainingHeaderPrefix):
+			t.quota.Remaining = &n
+		default:
+			continue
+		}
+		t.quota.Updated_At = time.Now()
+	}
+}
+
+func (t *quotaTracker) get() Quota {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.quota
+}
@@ -0,0 +1,33 @@
+package exchangeratesapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Quota(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Month", "1000")
+		w.Header().Set("X-RateLimit-Remaining-Month", "998")
+		w.Write([]byte(`{"success": true, "base": "EUR", "rates": {"USD": 1.07}}`))
+	}))
+	defer srv.Close()
+
+	cl, err := new(WithBaseURL(srv.URL), WithAPIKey(testAPIKey))
+	require.NoError(t, err)
+
+	require.Nil(t, cl.Quota().Limit)
+
+	_, err = cl.GetLatestRate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+
+	quota := cl.Quota()
+	require.Equal(t, int64(1), quota.Calls)
+	require.Equal(t, 1000, *quota.Limit)
+	require.Equal(t, 998, *quota.Remaining)
+	require.False(t, quota.Updated_At.IsZero())
+}
@@ -4,13 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Doer interface {
@@ -33,6 +41,10 @@ type Call struct {
 
 type CallOption func(*http.Request)
 
+// Observer is notified after every call with the response status code, which
+// is zero when no response was received, the call duration and its error.
+type Observer func(apiName string, c Call, code int, duration time.Duration, err error)
+
 type Client struct {
 	cl               Doer
 	apiName          string
@@ -42,6 +54,10 @@ type Client struct {
 	isResponseOK     func(code int, body []byte) bool
 	parseResponse    func(body []byte, header http.Header, v any) error
 	parseErrResponse func(code int, body []byte, header http.Header, v any) error
+	observers        []Observer
+	tracing          bool
+	logger           *slog.Logger
+	redactedParams   []string
 }
 
 type ClientOption func(*Client)
@@ -88,12 +104,41 @@ func WithAuthHeader(typ, cred string) ClientOption {
 	}
 }
 
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithRedactedParams hides the values of the given query parameters and
+// headers, in addition to the default ones, from debug output and errors.
+func WithRedactedParams(names ...string) ClientOption {
+	return func(c *Client) {
+		c.redactedParams = append(c.redactedParams, names...)
+	}
+}
+
+// WithDebug logs every request and response with secrets redacted.
 func WithDebug() ClientOption {
 	return func(c *Client) {
 		c.debug = true
 	}
 }
 
+func WithObserver(o Observer) ClientOption {
+	return func(c *Client) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+// WithTracing creates a client span for every call and propagates it to the
+// server with the W3C traceparent header.
+func WithTracing() ClientOption {
+	return func(c *Client) {
+		c.tracing = true
+	}
+}
+
 func WithParseResponse(fn func(body []byte, header http.Header, val any) error) ClientOption {
 	return func(c *Client) {
 		c.parseResponse = fn
@@ -111,7 +156,17 @@ func (d *Client) Do(ctx context.Context, c Call, opts ...CallOption) error {
 	return d.doOnce(ctx, c, opts...)
 }
 
-func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) error {
+func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) (err error) {
+	var code int
+	if len(d.observers) > 0 {
+		start := time.Now()
+		defer func() {
+			for _, o := range d.observers {
+				o(d.apiName, c, code, time.Since(start), err)
+			}
+		}()
+	}
+
 	u := d.baseURL
 	if c.BaseURL != "" {
 		u = c.BaseURL
@@ -130,6 +185,12 @@ func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) error {
 		return fmt.Errorf("base url plus call path may not contain query parameters")
 	}
 
+	if d.tracing {
+		var span trace.Span
+		req, span = d.startSpan(req, c)
+		defer func() { endSpan(span, code, err) }()
+	}
+
 	if len(c.Query) > 0 {
 		req.URL.RawQuery = c.Query.Encode()
 	}
@@ -159,17 +220,27 @@ func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) error {
 	}
 
 	if d.debug || c.Debug {
-		log.Printf("httpx request\nurl: %s\nbody: %s\nheaders: %s",
-			req.URL, string(reqBody), fmt.Sprint(c.RequestHeaders))
+		d.logger.InfoContext(ctx, "httpx request",
+			slog.String("api", d.apiName),
+			slog.String("url", d.redactURL(req.URL)),
+			slog.String("body", string(reqBody)),
+			slog.Any("headers", d.redactHeaders(req.Header)))
 	}
 
 	res, err := d.cl.Do(req)
 	if err != nil {
+		// Transport errors embed the full url, including secret query parameters
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = d.redactURL(req.URL)
+		}
 		return err
 	}
 
 	defer func() { _ = res.Body.Close() }()
 
+	code = res.StatusCode
+
 	var resBody []byte
 	if c.Response != nil || c.ErrResponse != nil {
 		resBody, err = io.ReadAll(res.Body)
@@ -179,8 +250,10 @@ func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) error {
 	}
 
 	if d.debug || c.Debug {
-		log.Printf("httpx response\ncode: %d\nbody: %s",
-			res.StatusCode, strings.TrimSpace(string(resBody)))
+		d.logger.InfoContext(ctx, "httpx response",
+			slog.String("api", d.apiName),
+			slog.Int("code", res.StatusCode),
+			slog.String("body", strings.TrimSpace(string(resBody))))
 	}
 
 	if c.ResponseHeaders != nil {
@@ -206,6 +279,40 @@ func (d *Client) doOnce(ctx context.Context, c Call, opts ...CallOption) error {
 	return nil
 }
 
+func (d *Client) startSpan(req *http.Request, c Call) (*http.Request, trace.Span) {
+	name := c.Label
+	if name == "" {
+		name = req.Method
+	}
+
+	ctx, span := tracing.Tracer().Start(req.Context(), d.apiName+" "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, span
+}
+
+func endSpan(span trace.Span, code int, err error) {
+	if code != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
 func (c *Client) GetClient() Doer {
 	return c.cl
 }
@@ -222,6 +329,8 @@ func NewClient(apiName string, baseURL string, opts ...ClientOption) *Client {
 		isResponseOK:     isResponseOK,
 		parseResponse:    parseJSONresponse,
 		parseErrResponse: parseErrResponse,
+		logger:           slog.Default(),
+		redactedParams:   append([]string(nil), _defaultRedactedParams...),
 	}
 
 	for _, opt := range opts {
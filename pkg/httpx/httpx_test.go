@@ -1,18 +1,26 @@
 package httpx_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ZakirAvrora/exchange-rate/pkg/httpx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type testDTO struct {
@@ -212,3 +220,74 @@ func TestDefaultHTTPClientWithTimeout(t *testing.T) {
 		require.Fail(t, "must return default http.Client")
 	}
 }
+
+func TestWithTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceParent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	defer srv.Close()
+
+	c := httpx.NewClient("test", srv.URL, httpx.WithTracing())
+	err := c.Do(context.Background(), httpx.Call{
+		Method: http.MethodGet,
+		Path:   "path",
+		Label:  "label",
+	})
+
+	require.ErrorIs(t, err, httpx.ErrNokResponse)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "test label", spans[0].Name)
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Contains(t, traceParent, spans[0].SpanContext.SpanID().String())
+}
+
+func TestDebugRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "s3cr3t", r.URL.Query().Get("access_key"))
+		json.NewEncoder(w).Encode(testDTO{"bar"})
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := httpx.NewClient("test", srv.URL,
+		httpx.WithDebug(),
+		httpx.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+	)
+
+	err := c.Do(context.Background(), httpx.Call{
+		Method:         http.MethodGet,
+		Query:          url.Values{"access_key": {"s3cr3t"}, "base": {"EUR"}},
+		RequestHeaders: map[string]string{"Authorization": "Bearer t0ken"},
+		Response:       &testDTO{},
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "base=EUR")
+	require.Contains(t, out, "REDACTED")
+	require.NotContains(t, out, "s3cr3t")
+	require.NotContains(t, out, "t0ken")
+}
+
+func TestTransportErrorRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	c := httpx.NewClient("test", srv.URL)
+
+	err := c.Do(context.Background(), httpx.Call{
+		Method: http.MethodGet,
+		Query:  url.Values{"access_key": {"s3cr3t"}},
+	})
+	require.Error(t, err)
+	require.False(t, strings.Contains(err.Error(), "s3cr3t"), err.Error())
+}
@@ -0,0 +1,52 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const _redacted = "REDACTED"
+
+// _defaultRedactedParams are query parameters and headers whose values never
+// appear in logs or errors.
+var _defaultRedactedParams = []string{
+	"access_key", "api_key", "apikey", "key", "token", "password", "secret",
+	"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key",
+}
+
+func (d *Client) redacted(name string) bool {
+	for _, p := range d.redactedParams {
+		if strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Client) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	query := u.Query()
+	for k := range query {
+		if d.redacted(k) {
+			query.Set(k, _redacted)
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func (d *Client) redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for k := range redacted {
+		if d.redacted(k) {
+			redacted.Set(k, _redacted)
+		}
+	}
+	return redacted
+}
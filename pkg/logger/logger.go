@@ -0,0 +1,129 @@
+// Package logger builds the service's log/slog logger and carries request
+// scoped attributes through contexts.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type loggerSettings struct {
+	level  *slog.LevelVar
+	format string
+	w      io.Writer
+}
+
+type Option func(*loggerSettings)
+
+// Level sets a shared level variable, so the level can be changed later.
+func Level(level *slog.LevelVar) Option {
+	return func(s *loggerSettings) {
+		s.level = level
+	}
+}
+
+// Format selects FormatJSON or FormatText.
+func Format(format string) Option {
+	return func(s *loggerSettings) {
+		s.format = format
+	}
+}
+
+func Writer(w io.Writer) Option {
+	return func(s *loggerSettings) {
+		s.w = w
+	}
+}
+
+// New returns a logger that adds the request id and trace id found in the
+// context of every *Context call.
+func New(opts ...Option) (*slog.Logger, error) {
+	s := &loggerSettings{
+		level:  new(slog.LevelVar),
+		format: FormatJSON,
+		w:      os.Stdout,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: s.level}
+
+	var h slog.Handler
+	switch s.format {
+	case FormatJSON:
+		h = slog.NewJSONHandler(s.w, handlerOpts)
+	case FormatText:
+		h = slog.NewTextHandler(s.w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("logger error: unknown format %q", s.format)
+	}
+
+	return slog.New(contextHandler{h}), nil
+}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return l, fmt.Errorf("logger error: %w", err)
+	}
+	return l, nil
+}
+
+// Err is the conventional attribute for errors.
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,57 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDAttribute(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logger.New(logger.Writer(&buf))
+	require.NoError(t, err)
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	l.InfoContext(ctx, "hello", slog.String("foo", "bar"))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "hello", entry["msg"])
+	require.Equal(t, "bar", entry["foo"])
+	require.Equal(t, "req-1", entry["request_id"])
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+
+	l, err := logger.New(logger.Writer(&buf), logger.Level(level), logger.Format(logger.FormatText))
+	require.NoError(t, err)
+
+	l.Info("hidden")
+	require.Empty(t, buf.String())
+
+	level.Set(slog.LevelInfo)
+	l.Info("shown")
+	require.Contains(t, buf.String(), "msg=shown")
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := logger.New(logger.Format("xml"))
+	require.Error(t, err)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := logger.ParseLevel(" debug ")
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelDebug, level)
+
+	_, err = logger.ParseLevel("verbose")
+	require.Error(t, err)
+}
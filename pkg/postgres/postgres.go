@@ -4,7 +4,7 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -20,6 +20,8 @@ type postgresSettings struct {
 	maxPoolSize  int
 	connAttempts int
 	connTimeout  time.Duration
+	tracing      bool
+	logger       *slog.Logger
 	pool         *pgxpool.Pool
 }
 
@@ -43,11 +45,25 @@ func ConnTimeout(timeout time.Duration) Option {
 	}
 }
 
+// Tracing creates a span for every query.
+func Tracing() Option {
+	return func(c *postgresSettings) {
+		c.tracing = true
+	}
+}
+
+func Logger(l *slog.Logger) Option {
+	return func(c *postgresSettings) {
+		c.logger = l
+	}
+}
+
 func New(url string, opts ...Option) (*pgxpool.Pool, error) {
 	pg := &postgresSettings{
 		maxPoolSize:  _defaultMaxPoolSize,
 		connAttempts: _defaultConnAttempts,
 		connTimeout:  _defaultConnTimeout,
+		logger:       slog.Default(),
 	}
 
 	for _, opt := range opts {
@@ -60,13 +76,17 @@ func New(url string, opts ...Option) (*pgxpool.Pool, error) {
 	}
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize)
+	if pg.tracing {
+		poolConfig.ConnConfig.Tracer = queryTracer{}
+	}
+
 	for pg.connAttempts > 0 {
 		pg.pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 		if err == nil {
 			break
 		}
 
-		log.Printf("trying to connect to postgres, attempts left: %d", pg.connAttempts)
+		pg.logger.Warn("trying to connect to postgres", slog.Int("attempts_left", pg.connAttempts))
 
 		time.Sleep(pg.connTimeout)
 
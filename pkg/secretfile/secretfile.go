@@ -0,0 +1,134 @@
+// Package secretfile reads secrets mounted as files, such as Kubernetes or
+// Docker secrets, and reads them again when they are rotated.
+package secretfile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/logger"
+)
+
+const _defaultInterval = 30 * time.Second
+
+var ErrEmpty = errors.New("secret file is empty")
+
+// File holds the values of a secret file, one per line or separated by
+// commas.
+type File struct {
+	path     string
+	interval time.Duration
+	logger   *slog.Logger
+	data     []byte
+	values   atomic.Pointer[[]string]
+}
+
+type Option func(*File)
+
+// Interval is how often the file is checked for changes.
+func Interval(d time.Duration) Option {
+	return func(f *File) {
+		f.interval = d
+	}
+}
+
+func Logger(l *slog.Logger) Option {
+	return func(f *File) {
+		f.logger = l
+	}
+}
+
+// Open reads the file, which must hold a value at least.
+func Open(path string, opts ...Option) (*File, error) {
+	f := &File{
+		path:     path,
+		interval: _defaultInterval,
+		logger:   slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if _, err := f.read(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Values returns the values read last.
+func (f *File) Values() []string {
+	return *f.values.Load()
+}
+
+// Watch reads the file again every interval until ctx is done. A file that
+// cannot be read, or is empty while it is being replaced, leaves the values
+// as they are. The returned channel is closed once it has stopped.
+func (f *File) Watch(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changed, err := f.read()
+				if err != nil {
+					// NoReturnErr: keep the values read last
+					f.logger.WarnContext(ctx, "secret file reload error", logger.Err(err), slog.String("path", f.path))
+					continue
+				}
+				if changed {
+					f.logger.InfoContext(ctx, "secret file reloaded", slog.String("path", f.path), slog.Int("values", len(f.Values())))
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// read stores the values of the file and reports whether they changed.
+func (f *File) read() (bool, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, fmt.Errorf("reading secret file is failed: %w", err)
+	}
+	if bytes.Equal(data, f.data) {
+		return false, nil
+	}
+
+	values := Parse(string(data))
+	if len(values) == 0 {
+		return false, fmt.Errorf("%w: %s", ErrEmpty, f.path)
+	}
+
+	f.data = data
+	f.values.Store(&values)
+	return true, nil
+}
+
+// Parse returns the values of s, one per line or separated by commas.
+func Parse(s string) []string {
+	var values []string
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == '\r' || r == ',' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,41 @@
+package secretfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte("first\nsecond\n"), 0o600))
+
+	f, err := Open(path, Interval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second"}, f.Values())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := f.Watch(ctx)
+
+	// A file emptied while it is replaced keeps the values read last
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, []string{"first", "second"}, f.Values())
+
+	require.NoError(t, os.WriteFile(path, []byte("rotated, second"), 0o600))
+	require.Eventually(t, func() bool {
+		values := f.Values()
+		return len(values) == 2 && values[0] == "rotated"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	require.NoError(t, os.WriteFile(path, []byte(" \n"), 0o600))
+	_, err = Open(path)
+	require.ErrorIs(t, err, ErrEmpty)
+}
@@ -0,0 +1,152 @@
+// Package tracing sets up OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer of every span the service creates.
+const InstrumentationName = "github.com/ZakirAvrora/exchange-rate"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+const (
+	_defaultServiceName = "exchange-rate"
+	_defaultSampleRatio = 1.0
+)
+
+type tracingSettings struct {
+	exporter     string
+	otlpEndpoint string
+	serviceName  string
+	sampleRatio  float64
+}
+
+type Option func(*tracingSettings)
+
+// Exporter selects ExporterNone, ExporterStdout or ExporterOTLP.
+func Exporter(name string) Option {
+	return func(s *tracingSettings) {
+		s.exporter = name
+	}
+}
+
+// OTLPEndpoint sets the OTLP/HTTP collector URL. When empty, the standard
+// OTEL_EXPORTER_OTLP_* environment variables apply.
+func OTLPEndpoint(url string) Option {
+	return func(s *tracingSettings) {
+		s.otlpEndpoint = url
+	}
+}
+
+func ServiceName(name string) Option {
+	return func(s *tracingSettings) {
+		s.serviceName = name
+	}
+}
+
+// SampleRatio sets the fraction of new traces that are sampled.
+func SampleRatio(ratio float64) Option {
+	return func(s *tracingSettings) {
+		s.sampleRatio = ratio
+	}
+}
+
+// New installs the global tracer provider and W3C trace context propagator.
+// The returned function flushes and stops the exporter.
+func New(ctx context.Context, opts ...Option) (func(context.Context) error, error) {
+	s := &tracingSettings{
+		exporter:    ExporterNone,
+		serviceName: _defaultServiceName,
+		sampleRatio: _defaultSampleRatio,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch s.exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var otlpOpts []otlptracehttp.Option
+		if s.otlpEndpoint != "" {
+			otlpOpts = append(otlpOpts, otlptracehttp.WithEndpointURL(s.otlpEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, otlpOpts...)
+	default:
+		return nil, fmt.Errorf("tracing error: unknown exporter %q", s.exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing error: creating exporter error: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(s.serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing error: creating resource error: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the service's tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or an empty
+// string when there is none.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// LinkFromTraceParent returns a link to the span identified by traceParent.
+// It reports false when traceParent is not a valid W3C traceparent.
+func LinkFromTraceParent(traceParent string) (trace.Link, bool) {
+	carrier := propagation.MapCarrier{"traceparent": traceParent}
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+
+	return trace.Link{SpanContext: sc}, true
+}
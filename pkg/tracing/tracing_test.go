@@ -0,0 +1,32 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZakirAvrora/exchange-rate/pkg/tracing"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceParentLink(t *testing.T) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sdktrace.NewSimpleSpanProcessor(tracetest.NewInMemoryExporter())))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	traceParent := tracing.TraceParent(ctx)
+	require.NotEmpty(t, traceParent)
+
+	link, ok := tracing.LinkFromTraceParent(traceParent)
+	require.True(t, ok)
+	require.Equal(t, span.SpanContext().TraceID(), link.SpanContext.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), link.SpanContext.SpanID())
+}
+
+func TestTraceParentWithoutSpan(t *testing.T) {
+	require.Empty(t, tracing.TraceParent(context.Background()))
+
+	_, ok := tracing.LinkFromTraceParent("")
+	require.False(t, ok)
+}